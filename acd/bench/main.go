@@ -5,8 +5,10 @@ package main
 
 import (
 	"crypto/elliptic"
+	"fmt"
 
 	"github.com/qantik/ratcheted/acd"
+	"github.com/qantik/ratcheted/primitives"
 	"github.com/qantik/ratcheted/primitives/encryption"
 	"github.com/qantik/ratcheted/primitives/signature"
 )
@@ -17,10 +19,46 @@ var (
 	ecdsa = signature.NewECDSA(curve)
 	gcm   = encryption.NewGCM()
 
+	chacha  = encryption.NewChaCha20Poly1305()
+	xchacha = encryption.NewXChaCha20Poly1305()
+	siv     = encryption.NewGCMSIV()
+	otsiv   = encryption.NewOneTimeGCMSIV()
+
 	dr   = acd.NewDoubleRatchet(gcm, nil, nil)
 	drpk = acd.NewDoubleRatchet(gcm, ecies, ecdsa)
+
+	// The RSA instances use supported modulus sizes, their construction cannot fail.
+	pss2048, _  = signature.NewPSS(primitives.RSA2048)
+	pss3072, _  = signature.NewPSS(primitives.RSA3072)
+	oaep2048, _ = encryption.NewOAEPGCM(primitives.RSA2048)
+	oaep3072, _ = encryption.NewOAEPGCM(primitives.RSA3072)
 )
 
+// aeads lists the AEAD schemes the double ratchet is compared with.
+var aeads = []struct {
+	name string
+	aead encryption.Authenticated
+}{
+	{"AES-GCM", gcm},
+	{"ChaCha20-Poly1305", chacha},
+	{"XChaCha20-Poly1305", xchacha},
+	{"AES-GCM-SIV", siv},
+	{"AES-GCM-SIV (one-time key)", otsiv},
+}
+
+// configs lists the public-key configurations the double ratchet is compared with.
+var configs = []struct {
+	name string
+	pke  encryption.Asymmetric
+	sig  signature.Signature
+}{
+	{"P-256", ecies, ecdsa},
+	{"X25519/Ed25519", encryption.NewX25519ECIES(), signature.NewEd25519()},
+	{"X25519/Ed448", encryption.NewX25519ECIES(), signature.NewEd448()},
+	{"RSA-2048", oaep2048, pss2048},
+	{"RSA-3072", oaep3072, pss3072},
+}
+
 var (
 	msg = []byte("msg")
 	ad  = []byte("ad")
@@ -30,4 +68,40 @@ func main() {
 	size(drpk, size_alt)
 	size(drpk, size_uni)
 	size(drpk, size_def)
+
+	compare()
+	compareConfigs()
+}
+
+// compareConfigs runs the alternating runtime and state size benchmarks for the EC, EdDSA and
+// RSA configurations and reports the per-message size savings with respect to P-256, negative
+// values denoting larger messages.
+func compareConfigs() {
+	base := acd.NewDoubleRatchet(gcm, ecies, ecdsa)
+
+	for _, c := range configs {
+		dr := acd.NewDoubleRatchet(gcm, c.pke, c.sig)
+
+		fmt.Println("Runtime (ALT)", c.name)
+		time(dr, time_alt)
+		fmt.Println("Maximum State Size (ALT)", c.name)
+		size(dr, size_alt)
+		fmt.Println("Per-Message Size Savings (ALT)", c.name)
+		savings(dr, base, size_alt)
+	}
+}
+
+// compare runs the alternating runtime benchmark for each AEAD scheme and reports the
+// per-message size savings with respect to AES-GCM.
+func compare() {
+	base := acd.NewDoubleRatchet(gcm, ecies, ecdsa)
+
+	for _, a := range aeads {
+		dr := acd.NewDoubleRatchet(a.aead, ecies, ecdsa)
+
+		fmt.Println("Runtime (ALT)", a.name)
+		time(dr, time_alt)
+		fmt.Println("Per-Message Size Savings (ALT)", a.name)
+		savings(dr, base, size_alt)
+	}
 }
@@ -112,3 +112,67 @@ func Test_DefUnidirectional(t *testing.T) {
 		require.True(bytes.Equal(msg, pt))
 	}
 }
+
+func Test_AEAD(t *testing.T) {
+	require := require.New(t)
+
+	aeads := []encryption.Authenticated{
+		gcm, encryption.NewChaCha20Poly1305(), encryption.NewXChaCha20Poly1305(),
+		encryption.NewGCMSIV(), encryption.NewOneTimeGCMSIV(),
+	}
+
+	for _, aead := range aeads {
+		dr := NewDoubleRatchet(aead, ecies, ecdsa)
+
+		alice, bob, err := dr.Init()
+		require.Nil(err)
+
+		var cts [5][]byte
+		for i := 0; i < 5; i++ {
+			cts[i], err = dr.Send(alice, msg)
+			require.Nil(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			ct, err := dr.Send(bob, msg)
+			require.Nil(err)
+
+			pt, err := dr.Receive(alice, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+
+		for i := 0; i < 5; i++ {
+			pt, err := dr.Receive(bob, cts[i])
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
+
+func Test_EdDSA(t *testing.T) {
+	require := require.New(t)
+
+	for _, dss := range []signature.Signature{signature.NewEd25519(), signature.NewEd448()} {
+		dr := NewDoubleRatchet(gcm, ecies, dss)
+
+		alice, bob, err := dr.Init()
+		require.Nil(err)
+
+		for i := 0; i < 5; i++ {
+			ct, err := dr.Send(alice, msg)
+			require.Nil(err)
+
+			pt, err := dr.Receive(bob, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+
+			ct, err = dr.Send(bob, msg)
+			require.Nil(err)
+
+			pt, err = dr.Receive(alice, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/qantik/ratcheted/primitives"
 	"github.com/qantik/ratcheted/primitives/encryption"
 	"github.com/qantik/ratcheted/primitives/signature"
 )
@@ -18,7 +19,7 @@ var (
 	curve = elliptic.P256()
 	ecies = encryption.NewECIES(curve)
 	ecdsa = signature.NewECDSA(curve)
-	aes   = encryption.NewAES()
+	aes   = encryption.NewAESHMAC()
 	gcm   = encryption.NewGCM()
 
 	arcad     = NewARCAD(ecdsa, ecies, aes)
@@ -111,3 +112,104 @@ func TestARCAD_DefUnidirectional(t *testing.T) {
 		require.True(bytes.Equal(msg, pt))
 	}
 }
+
+func TestARCAD_X25519(t *testing.T) {
+	require := require.New(t)
+
+	msg := []byte("arcad")
+	ad := []byte("ad")
+
+	arcad := NewARCAD(ecdsa, encryption.NewX25519ECIES(), aes)
+
+	alice, bob, err := arcad.Init()
+	require.Nil(err)
+
+	var cts [5][]byte
+	for i := 0; i < 5; i++ {
+		cts[i], err = arcad.Send(alice, ad, msg)
+		require.Nil(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		ct, err := arcad.Send(bob, ad, msg)
+		require.Nil(err)
+
+		pt, err := arcad.Receive(alice, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 5; i++ {
+		pt, err := arcad.Receive(bob, ad, cts[i])
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
+
+func TestARCAD_RSA(t *testing.T) {
+	require := require.New(t)
+
+	msg := []byte("arcad")
+	ad := []byte("ad")
+
+	pss, err := signature.NewPSS(primitives.RSA2048)
+	require.Nil(err)
+	oaep, err := encryption.NewOAEPGCM(primitives.RSA2048)
+	require.Nil(err)
+
+	arcad := NewARCAD(pss, oaep, aes)
+
+	alice, bob, err := arcad.Init()
+	require.Nil(err)
+
+	for i := 0; i < 3; i++ {
+		ct, err := arcad.Send(alice, ad, msg)
+		require.Nil(err)
+
+		pt, err := arcad.Receive(bob, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		ct, err = arcad.Send(bob, ad, msg)
+		require.Nil(err)
+
+		pt, err = arcad.Receive(alice, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
+
+func TestARCAD_EdDSA(t *testing.T) {
+	require := require.New(t)
+
+	msg := []byte("arcad")
+	ad := []byte("ad")
+
+	for _, sig := range []signature.Signature{signature.NewEd25519(), signature.NewEd448()} {
+		arcad := NewARCAD(sig, ecies, aes)
+
+		alice, bob, err := arcad.Init()
+		require.Nil(err)
+
+		var cts [5][]byte
+		for i := 0; i < 5; i++ {
+			cts[i], err = arcad.Send(alice, ad, msg)
+			require.Nil(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			ct, err := arcad.Send(bob, ad, msg)
+			require.Nil(err)
+
+			pt, err := arcad.Receive(alice, ad, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+
+		for i := 0; i < 5; i++ {
+			pt, err := arcad.Receive(bob, ad, cts[i])
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
@@ -6,8 +6,10 @@ package main
 
 import (
 	"crypto/elliptic"
+	"fmt"
 
 	"github.com/qantik/ratcheted/dv"
+	"github.com/qantik/ratcheted/primitives"
 	"github.com/qantik/ratcheted/primitives/encryption"
 	"github.com/qantik/ratcheted/primitives/signature"
 )
@@ -17,7 +19,7 @@ var (
 	ecies = encryption.NewECIES(curve)
 	ecdsa = signature.NewECDSA(curve)
 	gcm   = encryption.NewGCM()
-	aes   = encryption.NewAES()
+	aes   = encryption.NewAESHMAC()
 
 	flag = 250
 
@@ -26,8 +28,48 @@ var (
 	hybrid = dv.NewHybridARCAD(ecdsa, ecies, aes, gcm, flag)
 	block  = dv.NewBlockchainARCAD(hybrid)
 	sarcad = dv.NewSARCAD(gcm)
+
+	chacha  = encryption.NewChaCha20Poly1305()
+	xchacha = encryption.NewXChaCha20Poly1305()
+	siv     = encryption.NewGCMSIV()
+	otsiv   = encryption.NewOneTimeGCMSIV()
+
+	x25519      = encryption.NewX25519ECIES()
+	arcadX25519 = dv.NewARCAD(ecdsa, x25519, aes)
+
+	// The RSA instances use supported modulus sizes, their construction cannot fail.
+	pss2048, _  = signature.NewPSS(primitives.RSA2048)
+	pss3072, _  = signature.NewPSS(primitives.RSA3072)
+	oaep2048, _ = encryption.NewOAEPGCM(primitives.RSA2048)
+	oaep3072, _ = encryption.NewOAEPGCM(primitives.RSA3072)
 )
 
+// aeads lists the AEAD schemes the protocols are compared with.
+var aeads = []struct {
+	name string
+	aead encryption.Authenticated
+}{
+	{"AES-GCM", gcm},
+	{"ChaCha20-Poly1305", chacha},
+	{"XChaCha20-Poly1305", xchacha},
+	{"AES-GCM-SIV", siv},
+	{"AES-GCM-SIV (one-time key)", otsiv},
+}
+
+// configs lists the public-key configurations the signcryption-based protocols are
+// compared with.
+var configs = []struct {
+	name string
+	sig  signature.Signature
+	pke  encryption.Asymmetric
+}{
+	{"P-256", ecdsa, ecies},
+	{"Ed25519/X25519", signature.NewEd25519(), x25519},
+	{"Ed448/X25519", signature.NewEd448(), x25519},
+	{"RSA-2048", pss2048, oaep2048},
+	{"RSA-3072", pss3072, oaep3072},
+}
+
 var (
 	msg = []byte("msg")
 	ad  = []byte("ad")
@@ -35,4 +77,74 @@ var (
 
 func main() {
 	time(sarcad, time_alt)
+
+	compare()
+	compareECIES()
+	compareConfigs()
+}
+
+// compareConfigs runs the alternating benchmarks of ARCAD and hybrid-ARCAD for the EC, EdDSA and
+// RSA configurations. Since ARCAD generates fresh key pairs with every message, the RSA runtimes
+// are dominated by the key generation.
+func compareConfigs() {
+	for _, c := range configs {
+		protocols := []struct {
+			name string
+			p    dv.Protocol
+		}{
+			{"ARCAD", dv.NewARCAD(c.sig, c.pke, aes)},
+			{"hybrid-ARCAD", dv.NewHybridARCAD(c.sig, c.pke, aes, gcm, flag)},
+		}
+
+		for _, p := range protocols {
+			fmt.Println("Runtime (ALT)", p.name, c.name)
+			time(p.p, time_alt)
+			fmt.Println("Total Message Size (ALT)", p.name, c.name)
+			size(p.p, size_alt)
+		}
+	}
+}
+
+// compareECIES reports the message sizes of ARCAD with the P-256 and the compact X25519
+// ECIES variants. The X25519 variant encodes an ephemeral key in 32 bytes and omits the
+// AES-GCM nonce, which shrinks every signcryption layer of an onion ciphertext.
+func compareECIES() {
+	fmt.Println("Total Message Size (ALT) ARCAD P-256 ECIES")
+	size(arcad, size_alt)
+	fmt.Println("Total Message Size (ALT) ARCAD X25519 ECIES")
+	size(arcadX25519, size_alt)
+	fmt.Println("Per-Message Size Savings (ALT) ARCAD X25519 ECIES")
+	savings(arcadX25519, arcad, size_alt)
+}
+
+// compare runs the alternating benchmarks of the AEAD-based protocols for each AEAD scheme
+// and reports the per-message size savings with respect to AES-GCM. One-time AES-GCM-SIV
+// omits the 12-byte nonce of every AEAD ciphertext, which saves 12 bytes per SARCAD message
+// and 24 bytes per lite-ARCAD message, whereas XChaCha20-Poly1305 adds 12 bytes each.
+func compare() {
+	protocols := func(aead encryption.Authenticated) []struct {
+		name string
+		p    dv.Protocol
+	} {
+		return []struct {
+			name string
+			p    dv.Protocol
+		}{
+			{"lite-ARCAD", dv.NewLiteARCAD(aead, aes)},
+			{"SARCAD", dv.NewSARCAD(aead)},
+			{"hybrid-ARCAD", dv.NewHybridARCAD(ecdsa, ecies, aes, aead, flag)},
+		}
+	}
+	base := protocols(gcm)
+
+	for _, a := range aeads {
+		for i, p := range protocols(a.aead) {
+			fmt.Println("Runtime (ALT)", p.name, a.name)
+			time(p.p, time_alt)
+			fmt.Println("Total Message Size (ALT)", p.name, a.name)
+			size(p.p, size_alt)
+			fmt.Println("Per-Message Size Savings (ALT)", p.name, a.name)
+			savings(p.p, base[i].p, size_alt)
+		}
+	}
 }
@@ -117,3 +117,15 @@ func max(a, b int) int {
 	}
 	return a
 }
+
+// savings prints the average number of bytes per message that a protocol saves with
+// respect to a baseline instance.
+func savings(p, base dv.Protocol, tp func(p dv.Protocol, i int) (int, int)) {
+	s := ""
+	for _, n := range []int{50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200} {
+		m, _ := tp(p, n)
+		b, _ := tp(base, n)
+		s += fmt.Sprintf("(%d,%.2f)", n, float32(b-m)/float32(n))
+	}
+	fmt.Println(s)
+}
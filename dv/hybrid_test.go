@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives/encryption"
 )
 
 var (
@@ -102,3 +104,41 @@ func TestHybridARCAD_DefUnidirectional(t *testing.T) {
 		require.True(bytes.Equal(msg, pt))
 	}
 }
+
+func TestHybridARCAD_AEAD(t *testing.T) {
+	require := require.New(t)
+
+	msg := []byte("arcad")
+	ad := []byte("ad")
+
+	for _, aead := range []encryption.Authenticated{
+		encryption.NewChaCha20Poly1305(), encryption.NewXChaCha20Poly1305(),
+		encryption.NewGCMSIV(), encryption.NewOneTimeGCMSIV(),
+	} {
+		hybrid := NewHybridARCAD(ecdsa, ecies, aes, aead, flag)
+
+		alice, bob, err := hybrid.Init()
+		require.Nil(err)
+
+		var cts [5][]byte
+		for i := 0; i < 5; i++ {
+			cts[i], err = hybrid.Send(alice, ad, msg)
+			require.Nil(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			ct, err := hybrid.Send(bob, ad, msg)
+			require.Nil(err)
+
+			pt, err := hybrid.Receive(alice, ad, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+
+		for i := 0; i < 5; i++ {
+			pt, err := hybrid.Receive(bob, ad, cts[i])
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
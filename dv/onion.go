@@ -126,9 +126,13 @@ func (o onion) receive(s [][]byte, hk, ad, ct []byte) (upd, msg []byte, err erro
 	}
 
 	n := len(s)
+	if len(c.CT) != n+1 {
+		return nil, nil, errors.New("invalid number of onion layers")
+	}
 
-	k := make([]byte, 16)
-
+	// The associated data of each layer only depends on the ciphertexts, such that all
+	// layers can be unsigncrypted and their signatures verified at once.
+	skr, pks, ads := make([][]byte, n), make([][]byte, n), make([][]byte, n)
 	for i := n - 1; i >= 0; i-- {
 		var st onionReceiver
 		if err := binary.Unmarshal(s[i], &st); err != nil {
@@ -136,12 +140,16 @@ func (o onion) receive(s [][]byte, hk, ad, ct []byte) (upd, msg []byte, err erro
 		}
 
 		ad = primitives.Digest(sha256.New(), hk, ad, c.CT[i+1])
+		skr[i], pks[i], ads[i] = st.SKR, st.PKS, ad
+	}
 
-		tmp, err := o.sc.unsigncrypt(st.SKR, st.PKS, ad, c.CT[i])
-		if err != nil {
-			return nil, nil, errors.Wrap(err, "unable to decrypt onion ciphertext")
-		}
+	ks, err := o.sc.unsigncryptBatch(skr, pks, ads, c.CT[:n])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to decrypt onion ciphertext")
+	}
 
+	k := make([]byte, 16)
+	for _, tmp := range ks {
 		k = primitives.Xor(k, tmp)
 	}
 
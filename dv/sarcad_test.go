@@ -100,3 +100,36 @@ func TestSARCAD_DefUnidirectional(t *testing.T) {
 		require.True(bytes.Equal(msg, pt))
 	}
 }
+
+func TestSARCAD_AEAD(t *testing.T) {
+	require := require.New(t)
+
+	msg := []byte("sarcad")
+	ad := []byte("ad")
+
+	for _, aead := range []encryption.Authenticated{
+		encryption.NewChaCha20Poly1305(), encryption.NewXChaCha20Poly1305(),
+		encryption.NewGCMSIV(), encryption.NewOneTimeGCMSIV(),
+	} {
+		sarcad := NewSARCAD(aead)
+
+		alice, bob, err := sarcad.Init()
+		require.Nil(err)
+
+		for i := 0; i < 5; i++ {
+			ct, err := sarcad.Send(alice, ad, msg)
+			require.Nil(err)
+
+			pt, err := sarcad.Receive(bob, ad, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+
+			ct, err = sarcad.Send(bob, ad, msg)
+			require.Nil(err)
+
+			pt, err = sarcad.Receive(alice, ad, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
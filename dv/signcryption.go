@@ -56,18 +56,60 @@ func (s signcryption) signcrypt(sks, pkr, ad, msg []byte) ([]byte, error) {
 
 // unsigncrypt a ciphertext with associated data.
 func (s signcryption) unsigncrypt(skr, pks, ad, ct []byte) ([]byte, error) {
-	dec, err := s.encryption.Decrypt(pks, ct, nil)
+	b, err := s.decrypt(pks, ct)
 	if err != nil {
 		return nil, err
 	}
 
-	var b signcryptionBlock
-	if err := binary.Unmarshal(dec, &b); err != nil {
+	if err := s.signature.Verify(skr, append(b.AD, b.Message...), b.Signature); err != nil {
 		return nil, err
 	}
+	return b.Message, nil
+}
+
+// unsigncryptBatch unsigncrypts several ciphertexts at once. The signatures are checked
+// in a single batch if the signature scheme implements signature.BatchVerifier.
+func (s signcryption) unsigncryptBatch(skr, pks, ad, ct [][]byte) ([][]byte, error) {
+	batch, ok := s.signature.(signature.BatchVerifier)
+	if !ok {
+		msgs := make([][]byte, len(ct))
+		for i := range ct {
+			msg, err := s.unsigncrypt(skr[i], pks[i], ad[i], ct[i])
+			if err != nil {
+				return nil, err
+			}
+			msgs[i] = msg
+		}
+		return msgs, nil
+	}
 
-	if err := s.signature.Verify(skr, append(b.AD, b.Message...), b.Signature); err != nil {
+	msgs := make([][]byte, len(ct))
+	signed := make([][]byte, len(ct))
+	sigs := make([][]byte, len(ct))
+	for i := range ct {
+		b, err := s.decrypt(pks[i], ct[i])
+		if err != nil {
+			return nil, err
+		}
+		msgs[i], signed[i], sigs[i] = b.Message, append(b.AD, b.Message...), b.Signature
+	}
+
+	if err := batch.VerifyBatch(skr, signed, sigs); err != nil {
 		return nil, err
 	}
-	return b.Message, nil
+	return msgs, nil
+}
+
+// decrypt a ciphertext into a signcryption block.
+func (s signcryption) decrypt(pks, ct []byte) (*signcryptionBlock, error) {
+	dec, err := s.encryption.Decrypt(pks, ct, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var b signcryptionBlock
+	if err := binary.Unmarshal(dec, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/qantik/ratcheted/primitives"
 	"github.com/qantik/ratcheted/primitives/encryption"
 	"github.com/qantik/ratcheted/primitives/signature"
 )
@@ -40,3 +41,40 @@ func TestSigncryption(t *testing.T) {
 	require.Nil(t, err)
 	require.True(t, bytes.Equal(msg, pt))
 }
+
+func TestSigncryption_Batch(t *testing.T) {
+	require := require.New(t)
+
+	c := elliptic.P256()
+	ecies := encryption.NewECIES(c)
+
+	pss, err := signature.NewPSS(primitives.RSA2048)
+	require.Nil(err)
+
+	// ECDSA verifies the signatures in a batch whereas RSA-PSS verifies them one by one.
+	for _, sig := range []signature.Signature{signature.NewECDSA(c), pss} {
+		sc := &signcryption{ecies, sig}
+
+		var skr, pks, ads, cts, msgs [][]byte
+		for i := 0; i < 3; i++ {
+			sks, vk, err := sc.generateSignKeys()
+			require.Nil(err)
+			dk, pkr, err := sc.generateCipherKeys()
+			require.Nil(err)
+
+			msg, ad := []byte{byte(i)}, []byte{100, byte(i)}
+			ct, err := sc.signcrypt(sks, pkr, ad, msg)
+			require.Nil(err)
+
+			skr, pks, ads, cts, msgs = append(skr, vk), append(pks, dk), append(ads, ad), append(cts, ct), append(msgs, msg)
+		}
+
+		pts, err := sc.unsigncryptBatch(skr, pks, ads, cts)
+		require.Nil(err)
+		require.Equal(msgs, pts)
+
+		skr[0], skr[1] = skr[1], skr[0]
+		_, err = sc.unsigncryptBatch(skr, pks, ads, cts)
+		require.NotNil(err)
+	}
+}
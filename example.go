@@ -13,7 +13,7 @@ var (
 	curve = elliptic.P256()
 	ecies = encryption.NewECIES(curve)
 	ecdsa = signature.NewECDSA(curve)
-	aes   = encryption.NewAES()
+	aes   = encryption.NewAESHMAC()
 	gcm   = encryption.NewGCM()
 
 	flag = 100
@@ -6,6 +6,8 @@ package jmm
 import (
 	"bytes"
 	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -78,3 +80,71 @@ func TestSecMsg(t *testing.T) {
 	//	require.True(bytes.Equal(msg, pt))
 	//}
 }
+
+func TestSecMsg_EdDSA(t *testing.T) {
+	require := require.New(t)
+
+	ecies := encryption.NewECIES(elliptic.P256())
+
+	msg := []byte("secmsg")
+
+	for _, sig := range []signature.Signature{signature.NewEd25519(), signature.NewEd448()} {
+		sec := NewSecMsg(ecies, sig)
+
+		alice, bob, err := sec.Init()
+		require.Nil(err)
+
+		for i := 0; i < 5; i++ {
+			ct, err := sec.Send(alice, msg)
+			require.Nil(err)
+
+			pt, err := sec.Receive(bob, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+
+			ct, err = sec.Send(bob, msg)
+			require.Nil(err)
+
+			pt, err = sec.Receive(alice, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
+
+func TestSecMsg_OneTime(t *testing.T) {
+	require := require.New(t)
+
+	ecies := encryption.NewECIES(elliptic.P256())
+
+	msg := []byte("secmsg")
+
+	for _, ots := range []signature.Signature{
+		signature.NewWOTS(rand.Reader, sha256.New, 16),
+		signature.NewLamport(rand.Reader, sha256.New),
+	} {
+		sec := NewSecMsg(ecies, ots)
+
+		alice, bob, err := sec.Init()
+		require.Nil(err)
+
+		var cts [5][]byte
+		for i := 0; i < 5; i++ {
+			cts[i], err = sec.Send(alice, msg)
+			require.Nil(err)
+		}
+
+		for i := 0; i < 5; i++ {
+			pt, err := sec.Receive(bob, cts[i])
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+
+			ct, err := sec.Send(bob, msg)
+			require.Nil(err)
+
+			pt, err = sec.Receive(alice, ct)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+		}
+	}
+}
@@ -4,6 +4,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/qantik/ratcheted/js"
 	"github.com/qantik/ratcheted/primitives/hibe"
 	"github.com/qantik/ratcheted/primitives/signature"
@@ -21,6 +23,35 @@ var (
 	ad  = []byte("ad")
 )
 
+// fsgs lists the forward-secure signature schemes the protocol is compared with.
+var fsgs = []struct {
+	name string
+	fsg  signature.ForwardSignature
+}{
+	{"Bellare-Miner", fsg},
+	{"Abdalla-Reyzin", signature.NewAbdallaReyzin()},
+	{"XMSS", signature.NewXMSS(5)},
+	{"MMM sum Ed25519", signature.NewSum(signature.NewEd25519(), 10)},
+	{"MMM product Ed25519", signature.NewProduct(
+		signature.NewSum(signature.NewEd25519(), 5), signature.NewSum(signature.NewEd25519(), 5))},
+}
+
 func main() {
 	size(size_def)
+
+	compare()
+}
+
+// compare runs the alternating runtime and the deferred state size benchmarks for each
+// forward-secure signature scheme. Since every message carries a fresh kuDSS public key,
+// the key generation of the schemes is part of the runtime.
+func compare() {
+	for _, f := range fsgs {
+		sch = js.NewSCh(f.fsg, gentry)
+
+		fmt.Println("Runtime (ALT)", f.name)
+		time(time_alt)
+		fmt.Println("State Size (DEF)", f.name)
+		size(size_def)
+	}
 }
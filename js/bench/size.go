@@ -6,13 +6,15 @@ package main
 
 import (
 	"fmt"
+
+	"github.com/qantik/ratcheted/js"
 )
 
-func size_alt(n int) (int, int) {
+func size_alt(n int) (int, int, int) {
 	alice, bob, _ := sch.Init()
 
 	msgSize := 0
-	maxState := 0
+	maxState, maxEncoded := 0, 0
 
 	for i := 0; i < n/2; i++ {
 		ct, _ := sch.Send(alice, msg, msg)
@@ -20,26 +22,24 @@ func size_alt(n int) (int, int) {
 		_ = pt
 
 		msgSize += len(ct)
-		maxState = max(maxState, alice.Size())
-		maxState = max(maxState, bob.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, alice, bob)
 
 		ct, _ = sch.Send(bob, msg, msg)
 		pt, _ = sch.Receive(alice, msg, ct)
 		_ = pt
 
 		msgSize += len(ct)
-		maxState = max(maxState, alice.Size())
-		maxState = max(maxState, bob.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, alice, bob)
 	}
 
-	return msgSize, maxState
+	return msgSize, maxState, maxEncoded
 }
 
-func size_uni(n int) (int, int) {
+func size_uni(n int) (int, int, int) {
 	alice, bob, _ := sch.Init()
 
 	msgSize := 0
-	maxState := 0
+	maxState, maxEncoded := 0, 0
 
 	for i := 0; i < n/2; i++ {
 		ct, _ := sch.Send(alice, msg, msg)
@@ -47,8 +47,7 @@ func size_uni(n int) (int, int) {
 		_ = pt
 
 		msgSize += len(ct)
-		maxState = max(maxState, alice.Size())
-		maxState = max(maxState, bob.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, alice, bob)
 	}
 
 	for i := 0; i < n/2; i++ {
@@ -57,18 +56,17 @@ func size_uni(n int) (int, int) {
 		_ = pt
 
 		msgSize += len(ct)
-		maxState = max(maxState, alice.Size())
-		maxState = max(maxState, bob.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, alice, bob)
 	}
 
-	return msgSize, maxState
+	return msgSize, maxState, maxEncoded
 }
 
-func size_def(n int) (int, int) {
+func size_def(n int) (int, int, int) {
 	alice, bob, _ := sch.Init()
 
 	msgSize := 0
-	maxState := 0
+	maxState, maxEncoded := 0, 0
 
 	var cts [1000][]byte
 	for i := 0; i < n/2; i++ {
@@ -76,7 +74,7 @@ func size_def(n int) (int, int) {
 		cts[i] = ct
 
 		msgSize += len(ct)
-		maxState = max(maxState, alice.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, alice)
 	}
 
 	for i := 0; i < n/2; i++ {
@@ -85,30 +83,45 @@ func size_def(n int) (int, int) {
 		_ = pt
 
 		msgSize += len(ct)
-		maxState = max(maxState, alice.Size())
-		maxState = max(maxState, bob.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, alice, bob)
 	}
 
 	for i := 0; i < n/2; i++ {
 		pt, _ := sch.Receive(bob, msg, cts[i])
 		_ = pt
 
-		maxState = max(maxState, bob.Size())
+		maxState, maxEncoded = state(maxState, maxEncoded, bob)
 	}
 
-	return msgSize, maxState
+	return msgSize, maxState, maxEncoded
 }
 
-func size(tp func(i int) (int, int)) {
-	msg := make([]int, 10)
+func size(tp func(i int) (int, int, int)) {
+	st := make([]int, 10)
+	enc := make([]int, 10)
 
-	s := ""
+	s, e := "", ""
 	for i, n := range []int{50, 100, 200, 300, 400, 500, 600, 700, 800, 900} {
-		_, msg[i] = tp(n)
-		s += fmt.Sprintf("(%d,%.2f)", n, float32(msg[i])/1000)
+		_, st[i], enc[i] = tp(n)
+		s += fmt.Sprintf("(%d,%.2f)", n, float32(st[i])/1000)
+		e += fmt.Sprintf("(%d,%.2f)", n, float32(enc[i])/1000)
 		fmt.Println(s)
 	}
-	fmt.Println(s)
+	fmt.Println("Max State Size\n", s)
+	fmt.Println("Max Encoded State Size\n", e)
+}
+
+// state updates the maximum state size and the maximum encoded state size with the
+// current states of the given users. Unlike Size, the encoded size also accounts for
+// the slots of erased transcript entries and private keys.
+func state(maxState, maxEncoded int, users ...*js.User) (int, int) {
+	for _, u := range users {
+		maxState = max(maxState, u.Size())
+
+		data, _ := u.MarshalBinary()
+		maxEncoded = max(maxEncoded, len(data))
+	}
+	return maxState, maxEncoded
 }
 
 // func main() {
@@ -16,6 +16,14 @@ type kuDSS struct {
 	signature signature.ForwardSignature
 }
 
+// kuDSSLink certifies a fs-DSS public key that replaces an exhausted predecessor.
+type kuDSSLink struct {
+	VK   []byte // VK is the certified fs-DSS public key.
+	Cert []byte // Cert is the signature of the predecessor key on VK.
+
+	I int // I is the first period in which VK is used.
+}
+
 // kuDSSPublicKey bundles the public key material.
 type kuDSSPublicKey struct {
 	VK    []byte   // VK is the fs-DSS public key.
@@ -26,16 +34,19 @@ type kuDSSPublicKey struct {
 
 // kuDSSPrivateKey bundles the private key material.
 type kuDSSPrivateKey struct {
-	SK    []byte   // SK is the fs-DSS private key.
-	Sigma [][]byte // Sigma is an array of signed associated data.
+	SK    []byte      // SK is the fs-DSS private key.
+	Sigma [][]byte    // Sigma is an array of signed associated data.
+	Chain []kuDSSLink // Chain lists the certified replacements of the initial fs-DSS key.
 
 	I int // I is the current period of a live kuDSS instance.
 }
 
-// kuDSSSignature bundles the signature material.
+// kuDSSSignature bundles the signature material. Like Sigma, the certificate chain grows
+// linearly in the number of periods, with one link per MaxPeriod+1 periods.
 type kuDSSSignature struct {
-	Signature []byte   // Signature is the fs-DSS signature.
-	Sigma     [][]byte // Sigma is an array of signed associated data.
+	Signature []byte      // Signature is the fs-DSS signature.
+	Sigma     [][]byte    // Sigma is an array of signed associated data.
+	Chain     []kuDSSLink // Chain lists the certified replacements of the initial fs-DSS key.
 
 	I int // I is the period during which the signature has been created.
 }
@@ -51,7 +62,7 @@ func (k kuDSS) generate() (pk, sk []byte, err error) {
 	if err != nil {
 		return
 	}
-	sk, err = binary.Marshal(&kuDSSPrivateKey{SK: fsk, Sigma: [][]byte{}, I: 0})
+	sk, err = binary.Marshal(&kuDSSPrivateKey{SK: fsk, Sigma: [][]byte{}, Chain: []kuDSSLink{}, I: 0})
 	return
 }
 
@@ -75,35 +86,85 @@ func (k kuDSS) updatePrivateKey(sk, delta []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	sigma, err := k.signature.Sign(private.SK, append([]byte{0}, delta...))
+	sigma, err := k.signWith(&private, append([]byte{0}, delta...))
 	if err != nil {
 		return nil, err
 	}
 	private.Sigma = append(private.Sigma, sigma)
 
-	upd, err := k.signature.Update(private.SK)
-	if err != nil {
-		return nil, err
+	// A fs-DSS key that has reached its last period is replaced by a fresh key pair
+	// whose public key is certified by the exhausted private key. The exhausted key is
+	// erased, hence the chain cannot be compressed by certifying the latest key with the
+	// initial one. After I periods the chain holds I/(MaxPeriod+1) links, which is small
+	// compared to Sigma that already grows by one signature per period.
+	if n := k.maxPeriod(); n > 0 && k.period(private.Chain, private.I) >= n {
+		vk, sk, err := k.signature.Generate()
+		if err != nil {
+			return nil, err
+		}
+		c, err := certificate(vk, private.I+1)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := k.signWith(&private, c)
+		if err != nil {
+			return nil, err
+		}
+		private.Chain = append(private.Chain, kuDSSLink{VK: vk, Cert: cert, I: private.I + 1})
+		private.SK = sk
+	} else {
+		upd, err := k.signature.Update(private.SK)
+		if err != nil {
+			return nil, err
+		}
+		private.SK = upd
 	}
-	private.SK = upd
 	private.I += 1
 
 	return binary.Marshal(&private)
 }
 
-// sign creates a signature of a message with a given private key.
-func (k kuDSS) sign(sk, msg []byte) ([]byte, error) {
+// sign creates a signature of a message with a given private key. It returns the
+// signature and the private key, which has to replace the given one since stateful fs-DSS
+// schemes advance their private keys with every signature.
+func (k kuDSS) sign(sk, msg []byte) (upd, sig []byte, err error) {
 	var private kuDSSPrivateKey
 	if err := binary.Unmarshal(sk, &private); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	sig, err := k.signature.Sign(private.SK, append([]byte{1}, msg...))
+	fsig, err := k.signWith(&private, append([]byte{1}, msg...))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	sig, err = binary.Marshal(&kuDSSSignature{
+		Signature: fsig, Sigma: private.Sigma, Chain: private.Chain, I: private.I,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	upd, err = binary.Marshal(&private)
+	if err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// signWith signs a message with the fs-DSS private key of a kuDSS private key. The fs-DSS
+// private key is replaced by its advanced version if the fs-DSS scheme is stateful.
+func (k kuDSS) signWith(private *kuDSSPrivateKey, msg []byte) ([]byte, error) {
+	s, ok := k.signature.(signature.Stateful)
+	if !ok {
+		return k.signature.Sign(private.SK, msg)
 	}
 
-	return binary.Marshal(&kuDSSSignature{Signature: sig, Sigma: private.Sigma, I: private.I})
+	upd, sig, err := s.SignUpdate(private.SK, msg)
+	if err != nil {
+		return nil, err
+	}
+	private.SK = upd
+	return sig, nil
 }
 
 // verify checks the validity of a signature.
@@ -121,16 +182,69 @@ func (k kuDSS) verify(pk, msg, sig []byte) error {
 		return errors.New("mismatch between signature and public key periods")
 	}
 
+	// Follow the certificate chain from the initial fs-DSS public key. Every link has
+	// to be certified by its predecessor.
+	vks := [][]byte{public.VK}
+	for j, link := range signature.Chain {
+		if link.I > public.I || (j > 0 && link.I <= signature.Chain[j-1].I) {
+			return errors.New("invalid fs-dss certificate chain")
+		}
+		c, err := certificate(link.VK, link.I)
+		if err != nil {
+			return err
+		}
+		if err := k.signature.Verify(vks[j], c, link.Cert); err != nil {
+			return err
+		}
+		vks = append(vks, link.VK)
+	}
+
+	// vk returns the fs-DSS public key that has been used in a given period.
+	vk := func(period int) []byte {
+		j := 0
+		for j < len(signature.Chain) && signature.Chain[j].I <= period {
+			j++
+		}
+		return vks[j]
+	}
+
 	plaintext := append([]byte{1}, msg...)
-	if err := k.signature.Verify(public.VK, plaintext, signature.Signature); err != nil {
+	if err := k.signature.Verify(vk(public.I), plaintext, signature.Signature); err != nil {
 		return err
 	}
 	for i := 0; i < public.I-1; i++ {
 		delta := append([]byte{0}, public.Delta[i]...)
 		sigma := signature.Sigma[i]
-		if err := k.signature.Verify(public.VK, delta, sigma); err != nil {
+		if err := k.signature.Verify(vk(i), delta, sigma); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// maxPeriod returns the last period in which a fs-DSS private key can be used. A value
+// of zero designates an unbounded number of key evolutions.
+func (k kuDSS) maxPeriod() int {
+	if b, ok := k.signature.(signature.Bounded); ok {
+		return b.MaxPeriod()
+	}
+	return 0
+}
+
+// period returns the fs-DSS period of the private key used in a given kuDSS period.
+func (k kuDSS) period(chain []kuDSSLink, i int) int {
+	if len(chain) == 0 {
+		return i
+	}
+	return i - chain[len(chain)-1].I
+}
+
+// certificate returns the message that is signed to certify a fs-DSS public key
+// replacing its predecessor in a given period.
+func certificate(vk []byte, i int) ([]byte, error) {
+	link, err := binary.Marshal(&kuDSSLink{VK: vk, I: i})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{2}, link...), nil
+}
@@ -23,9 +23,69 @@ func TestKUDSS(t *testing.T) {
 	delta := []byte("delta")
 
 	for i := 0; i < 10; i++ {
-		sig, err := k.sign(sk, msg)
+		upd, sig, err := k.sign(sk, msg)
 		require.Nil(err)
 		require.Nil(k.verify(pk, msg, sig))
+		sk = upd
+
+		pk, err = k.updatePublicKey(pk, delta)
+		require.Nil(err)
+		sk, err = k.updatePrivateKey(sk, delta)
+		require.Nil(err)
+	}
+}
+
+func TestKUDSS_Rollover(t *testing.T) {
+	require := require.New(t)
+
+	b := signature.NewBellare(signature.WithMaxPeriod(10))
+	k := &kuDSS{signature: b}
+
+	pk, sk, err := k.generate()
+	require.Nil(err)
+
+	msg := []byte("kuDSS")
+	delta := []byte("delta")
+
+	for i := 0; i < 2*b.MaxPeriod()+10; i++ {
+		if i%(b.MaxPeriod()/2) == 0 {
+			upd, sig, err := k.sign(sk, msg)
+			require.Nil(err)
+			require.Nil(k.verify(pk, msg, sig))
+			require.NotNil(k.verify(pk, []byte("abc"), sig))
+			sk = upd
+		}
+
+		pk, err = k.updatePublicKey(pk, delta)
+		require.Nil(err)
+		sk, err = k.updatePrivateKey(sk, delta)
+		require.Nil(err)
+	}
+
+	_, sig, err := k.sign(sk, msg)
+	require.Nil(err)
+	require.Nil(k.verify(pk, msg, sig))
+}
+
+func TestKUDSS_XMSS(t *testing.T) {
+	require := require.New(t)
+
+	x := signature.NewXMSS(2)
+	k := &kuDSS{signature: x}
+
+	pk, sk, err := k.generate()
+	require.Nil(err)
+
+	msg := []byte("kuDSS")
+	delta := []byte("delta")
+
+	for i := 0; i < 3*x.MaxPeriod(); i++ {
+		for j := 0; j < 2; j++ {
+			upd, sig, err := k.sign(sk, msg)
+			require.Nil(err)
+			require.Nil(k.verify(pk, msg, sig))
+			sk = upd
+		}
 
 		pk, err = k.updatePublicKey(pk, delta)
 		require.Nil(err)
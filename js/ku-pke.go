@@ -10,6 +10,9 @@ import (
 	"github.com/qantik/ratcheted/primitives/hibe"
 )
 
+// kuPKESeedSize is the size of the seed from which a key pair is derived in bytes.
+const kuPKESeedSize = 16
+
 // kuPKE implements the key-updatable public-key encryption scheme based on a HIBE.
 type kuPKE struct {
 	hibe hibe.HIBE
@@ -28,11 +31,25 @@ type kuPKECiphertext struct {
 
 // generate creates a fresh public/private key pair.
 func (k kuPKE) generate() (pk, sk []byte, err error) {
-	var seed [16]byte
-	if _, err := rand.Read(seed[:]); err != nil {
+	seed, err := k.seed()
+	if err != nil {
 		return nil, nil, err
 	}
-	params, root, err := k.hibe.Setup(seed[:])
+	return k.derive(seed)
+}
+
+// seed samples a fresh key pair seed.
+func (k kuPKE) seed() ([]byte, error) {
+	seed := make([]byte, kuPKESeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// derive deterministically creates a public/private key pair from a seed.
+func (k kuPKE) derive(seed []byte) (pk, sk []byte, err error) {
+	params, root, err := k.hibe.Setup(seed)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -48,6 +65,16 @@ func (k kuPKE) generate() (pk, sk []byte, err error) {
 	return
 }
 
+// maxUpdates returns the number of updates a key pair supports before it has to be
+// replaced. A value of zero designates an unbounded number of updates.
+func (k kuPKE) maxUpdates() int {
+	if b, ok := k.hibe.(hibe.Bounded); ok {
+		// The first hierarchy level is taken up by the entity extracted in derive.
+		return b.MaxDepth() - 1
+	}
+	return 0
+}
+
 // updatePublicKey creates a new public key.
 func (k kuPKE) updatePublicKey(pk, delta []byte) ([]byte, error) {
 	var public kuPKEPublicKey
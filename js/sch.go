@@ -35,13 +35,20 @@ type SCh struct {
 type User struct {
 	vk, sk []byte   // vk and sk are the kuDSS public/private key pair.
 	ek     []byte   // ek is the kuPKE public key.
-	dk     [][]byte // dk is an array of kuPKE private keys.
+	dk     [][]byte // dk is an array of kuPKE private keys starting at index ack.
 	hk     []byte   // hk is the hashing key.
 
 	tau []byte   // tau is the latest hash ciphertext.
-	t   [][]byte // t is the communication transcript.
+	t   [][]byte // t is the communication transcript starting at index ack.
 
 	s, r, ack int // s, r and ack are the send, receive and acknowledge counters.
+
+	// base is the send counter of the rollover message that established ek, it is
+	// zero if ek has been received from the other user.
+	base int
+	// rk is the kuPKE private key established by the latest rollover message of the
+	// other user. It is only kept as long as the other user is encrypting under it.
+	rk []byte
 }
 
 // message bundles the ciphertext, the signature and auxiliary update data and is the object
@@ -58,6 +65,12 @@ type message struct {
 type aux struct {
 	Vk, Ek, Ad, Tau, T []byte
 	S, R               int
+
+	// Base designates the rollover message whose key was used for the encryption.
+	Base int
+	// Roll is the encrypted seed of a fresh kuPKE key pair. It is only set when the
+	// key used by the sender approaches the maximum number of updates.
+	Roll []byte
 }
 
 // NewSCh returns a fresh secure channel instance for a given forward-secure signature
@@ -122,21 +135,16 @@ func (s SCh) Send(user *User, ad, pt []byte) ([]byte, error) {
 	}
 	user.dk = append(user.dk, dks)
 
-	// Auxiliary data is both included in both marshalled and unmarshalled form in the
-	// message sent such that the receiver only has to perform a single unmarshal operation.
-	aux := &aux{
-		Vk: vks, Ek: eks,
-		Ad: ad, Tau: user.tau, T: user.t[user.s-1],
-		S: user.s, R: user.r,
-	}
-	l, err := binary.Marshal(aux)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to marshal auxiliary data")
+	// The public key has to be updated with all messages sent since it was established,
+	// either by the other user or by a rollover message of this user.
+	from := user.ack
+	if user.base > 0 {
+		from = user.base
 	}
 
 	uek := user.ek
-	for i := user.ack + 1; i < user.s; i++ {
-		uek, err = s.kuPKE.updatePublicKey(uek, user.t[i])
+	for i := from + 1; i < user.s; i++ {
+		uek, err = s.kuPKE.updatePublicKey(uek, user.t[i-user.ack])
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to update ku-pke public key")
 		}
@@ -146,9 +154,42 @@ func (s SCh) Send(user *User, ad, pt []byte) ([]byte, error) {
 		return nil, errors.Wrap(err, "unable to encrypt message")
 	}
 
+	// If the receiver key would exceed its maximum number of updates with the next message
+	// a fresh key pair is rolled over. Its seed is encrypted under the current key such
+	// that the receiver is able to derive the private key.
+	var roll, ekr []byte
+	if n := s.kuPKE.maxUpdates(); n > 0 && user.s+1-from > n {
+		seed, err := s.kuPKE.seed()
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to sample ku-pke seed")
+		}
+		ekr, _, err = s.kuPKE.derive(seed)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to derive ku-pke key pair")
+		}
+		roll, err = s.kuPKE.encrypt(uek, seed)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to encrypt ku-pke seed")
+		}
+	}
+
+	// Auxiliary data is both included in both marshalled and unmarshalled form in the
+	// message sent such that the receiver only has to perform a single unmarshal operation.
+	aux := &aux{
+		Vk: vks, Ek: eks,
+		Ad: ad, Tau: user.tau, T: user.t[user.s-1-user.ack],
+		S: user.s, R: user.r,
+		Base: user.base, Roll: roll,
+	}
+	l, err := binary.Marshal(aux)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal auxiliary data")
+	}
+
 	// Sign the ciphertext and the marshalled auxiliary data before
-	// marshalling the resulting object.
-	sig, err := s.kuDSS.sign(user.sk, append(c, l...))
+	// marshalling the resulting object. The updated private key can be discarded since
+	// it is replaced by the fresh one below.
+	_, sig, err := s.kuDSS.sign(user.sk, append(c, l...))
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to sign message")
 	}
@@ -160,6 +201,11 @@ func (s SCh) Send(user *User, ad, pt []byte) ([]byte, error) {
 	user.t = append(user.t, primitives.Digest(sha256.New(), user.hk, msg))
 	user.sk = sks
 
+	if roll != nil {
+		user.ek = ekr
+		user.base = user.s
+	}
+
 	return msg, nil
 }
 
@@ -173,12 +219,13 @@ func (s SCh) Receive(user *User, ad, ct []byte) ([]byte, error) {
 	}
 
 	// Check whether users are still synchronized. The following three properties have to hold:
-	//   1. Sender sent counter must always be exactly be equal to receiver received counter + 1.
+	//   1. Sender sent counter must always be exactly be equal to receiver received counter + 1
+	//      and the sender receive counter must lie between the acknowledge and send counters.
 	//   2. Sender and receiver transcripts must always match on the latest entries.
 	//   3. Associated data in the message must equal the local receiver ad.
-	if msg.Aux.S != user.r+1 {
+	if msg.Aux.S != user.r+1 || msg.Aux.R < user.ack || msg.Aux.R > user.s {
 		return nil, errors.New("sent/receive counters are out-of-sync")
-	} else if !bytes.Equal(msg.Aux.Tau, user.t[msg.Aux.R]) || !bytes.Equal(msg.Aux.T, user.tau) {
+	} else if !bytes.Equal(msg.Aux.Tau, user.t[msg.Aux.R-user.ack]) || !bytes.Equal(msg.Aux.T, user.tau) {
 		return nil, errors.New("sender/receiver transcripts are out-of-sync")
 	} else if !bytes.Equal(msg.Aux.Ad, ad) {
 		return nil, errors.New("local and received associated data does not match")
@@ -186,25 +233,44 @@ func (s SCh) Receive(user *User, ad, ct []byte) ([]byte, error) {
 
 	uvk := user.vk
 	for i := user.ack + 1; i <= msg.Aux.R; i++ {
-		uvk, _ = s.kuDSS.updatePublicKey(uvk, user.t[i])
+		uvk, _ = s.kuDSS.updatePublicKey(uvk, user.t[i-user.ack])
 	}
 	if err := s.kuDSS.verify(uvk, append(msg.C, msg.L...), msg.Sig); err != nil {
 		return nil, errors.Wrap(err, "unable to verify signature")
 	}
 
 	user.r += 1
+
+	// Delete outdated data. Data is considered outdated once a user has completed a
+	// successful round-trip cycle (send and receive). Transcript entries and private keys
+	// preceding the acknowledge counter are erased and dropped from the state.
+	n := msg.Aux.R - user.ack
+	for i := 0; i < n; i++ {
+		user.t[i] = nil
+		user.dk[i] = nil
+	}
+	user.t, user.dk = user.t[n:], user.dk[n:]
 	user.ack = msg.Aux.R
 
-	pt, err := s.kuPKE.decrypt(user.dk[user.ack], msg.C)
+	udk := user.dk[0]
+	if msg.Aux.Base > 0 {
+		udk = user.rk
+	}
+	if len(udk) == 0 {
+		return nil, errors.New("ku-pke private key is not available")
+	}
+
+	pt, err := s.kuPKE.decrypt(udk, msg.C)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to decrypt ciphertext")
 	}
 
-	// Delete outdated data. Data is considered outdated once a user has completed a
-	// successful round-trip cycle (send and receive).
-	for i := 0; i < user.ack; i++ {
-		user.t[i] = nil
-		user.dk[i] = nil
+	var seed []byte
+	if len(msg.Aux.Roll) > 0 {
+		seed, err = s.kuPKE.decrypt(udk, msg.Aux.Roll)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decrypt ku-pke seed")
+		}
 	}
 
 	user.tau = primitives.Digest(sha256.New(), user.hk, ct)
@@ -213,22 +279,111 @@ func (s SCh) Receive(user *User, ad, ct []byte) ([]byte, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to update ku-dss private key")
 	}
-	for i := user.ack; i <= user.s; i++ {
+
+	// A rollover message renders all private keys of this user obsolete as the sender
+	// ignores every public key that was sent before the rollover has been received.
+	// The rollover key itself is discarded once the sender has switched back to a key
+	// of this user, otherwise it is updated like any other private key.
+	if seed != nil {
+		for i := range user.dk {
+			user.dk[i] = nil
+		}
+		_, user.rk, err = s.kuPKE.derive(seed)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to derive ku-pke key pair")
+		}
+	} else if msg.Aux.Base == 0 {
+		user.rk = nil
+	} else {
+		user.rk, err = s.kuPKE.updatePrivateKey(user.rk, user.tau)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to udpate ku-pke private key")
+		}
+	}
+
+	for i := range user.dk {
+		if len(user.dk[i]) == 0 {
+			continue
+		}
 		user.dk[i], err = s.kuPKE.updatePrivateKey(user.dk[i], user.tau)
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to udpate ku-pke private key")
 		}
 	}
+
 	user.sk = sks
 	user.vk = msg.Aux.Vk
-	user.ek = msg.Aux.Ek
+
+	// A public key is only accepted if it was created after the latest rollover message
+	// of this user has been received.
+	if msg.Aux.R >= user.base {
+		user.ek = msg.Aux.Ek
+		user.base = 0
+	}
 
 	return pt, nil
 }
 
+// userVersion is the version of the user encoding produced by MarshalBinary.
+const userVersion = 1
+
+// userPacket is a helper structure that enables marshalling.
+type userPacket struct {
+	Version int
+
+	VK, SK, EK []byte
+	DK         [][]byte
+	HK         []byte
+	Tau        []byte
+	T          [][]byte
+
+	S, R, Ack, Base int
+	RK              []byte
+}
+
+// MarshalBinary encodes the complete state of a user such that a session can be resumed
+// later on. Note, that a restored user can only be used with an SCh instance that has
+// been created with the same ku-DSS and ku-PKE primitives.
+func (u User) MarshalBinary() ([]byte, error) {
+	packet := &userPacket{
+		Version: userVersion,
+		VK:      u.vk, SK: u.sk, EK: u.ek, DK: u.dk, HK: u.hk,
+		Tau: u.tau, T: u.t,
+		S: u.s, R: u.r, Ack: u.ack, Base: u.base,
+		RK: u.rk,
+	}
+	return binary.Marshal(packet)
+}
+
+// UnmarshalBinary restores the state of a user encoded by MarshalBinary.
+func (u *User) UnmarshalBinary(data []byte) error {
+	var packet userPacket
+	if err := binary.Unmarshal(data, &packet); err != nil {
+		return errors.Wrap(err, "unable to decode user")
+	}
+	if packet.Version != userVersion {
+		return errors.Errorf("unsupported user encoding version %d", packet.Version)
+	}
+	if packet.S < 0 || packet.R < 0 || packet.Ack < 0 || packet.Ack > packet.S ||
+		packet.Base < 0 || packet.Base > packet.S {
+		return errors.New("inconsistent user state")
+	}
+	if len(packet.DK) != packet.S-packet.Ack+1 || len(packet.T) != packet.S-packet.Ack+1 {
+		return errors.New("inconsistent user state")
+	}
+
+	*u = User{
+		vk: packet.VK, sk: packet.SK, ek: packet.EK, dk: packet.DK, hk: packet.HK,
+		tau: packet.Tau, t: packet.T,
+		s: packet.S, r: packet.R, ack: packet.Ack, base: packet.Base,
+		rk: packet.RK,
+	}
+	return nil
+}
+
 // size returns the size of a user object in bytes.
 func (u User) Size() int {
-	total := 12 + len(u.vk) + len(u.ek) + len(u.sk) + len(u.hk) + len(u.tau)
+	total := 16 + len(u.vk) + len(u.ek) + len(u.sk) + len(u.hk) + len(u.tau) + len(u.rk)
 	for _, d := range u.dk {
 		total += len(d)
 	}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/alecthomas/binary"
 	"github.com/stretchr/testify/require"
 
 	"github.com/qantik/ratcheted/primitives/hibe"
@@ -114,3 +115,217 @@ func TestSCh_DefUnidirectional(t *testing.T) {
 		require.True(bytes.Equal(msg, pt))
 	}
 }
+
+func TestSCh_Rollover(t *testing.T) {
+	require := require.New(t)
+
+	b := hibe.NewBoneh()
+	s := NewSCh(signature.NewBellare(), b)
+
+	alice, bob, err := s.Init()
+	require.Nil(err)
+
+	// Bob sends a message that is only delivered once alice has rolled over her keys.
+	delayed, err := s.Send(bob, msg, msg)
+	require.Nil(err)
+
+	for i := 0; i < 3*b.MaxDepth(); i++ {
+		ct, err := s.Send(alice, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(bob, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	pt, err := s.Receive(alice, msg, delayed)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	for i := 0; i < 2*b.MaxDepth(); i++ {
+		ct, err := s.Send(alice, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(bob, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 5; i++ {
+		ct, err := s.Send(bob, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(alice, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		ct, err = s.Send(alice, msg, msg)
+		require.Nil(err)
+
+		pt, err = s.Receive(bob, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
+
+func TestSCh_Restore(t *testing.T) {
+	require := require.New(t)
+
+	b := hibe.NewBoneh()
+	s := NewSCh(signature.NewBellare(), b)
+
+	alice, bob, err := s.Init()
+	require.Nil(err)
+
+	restore := func(u *User) *User {
+		data, err := u.MarshalBinary()
+		require.Nil(err)
+
+		var r User
+		require.Nil(r.UnmarshalBinary(data))
+		require.Equal(u.Size(), r.Size())
+		return &r
+	}
+
+	send := func(sender, receiver *User) {
+		ct, err := s.Send(sender, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(receiver, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 5; i++ {
+		send(alice, bob)
+		alice, bob = restore(alice), restore(bob)
+		send(bob, alice)
+		alice = restore(alice)
+	}
+
+	// Deliver messages in both directions while the other user has messages in flight
+	// and keep restoring both users in between.
+	var cts [][]byte
+	for i := 0; i < 2*b.MaxDepth(); i++ {
+		ct, err := s.Send(alice, msg, msg)
+		require.Nil(err)
+		cts = append(cts, ct)
+		alice = restore(alice)
+
+		if i%3 == 0 {
+			send(bob, alice)
+			bob = restore(bob)
+		}
+	}
+	for _, ct := range cts {
+		bob = restore(bob)
+		pt, err := s.Receive(bob, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	alice = restore(alice)
+	send(bob, alice)
+	bob = restore(bob)
+	send(alice, bob)
+}
+
+func TestSCh_RestoreVersion(t *testing.T) {
+	require := require.New(t)
+
+	alice, _, err := sch.Init()
+	require.Nil(err)
+
+	data, err := alice.MarshalBinary()
+	require.Nil(err)
+
+	var packet userPacket
+	require.Nil(binary.Unmarshal(data, &packet))
+	packet.Version = userVersion + 1
+	data, err = binary.Marshal(&packet)
+	require.Nil(err)
+
+	var u User
+	require.NotNil(u.UnmarshalBinary(data))
+}
+
+func TestSCh_XMSS(t *testing.T) {
+	require := require.New(t)
+
+	x := signature.NewXMSS(2)
+	s := NewSCh(x, hibe.NewGentry())
+
+	alice, bob, err := s.Init()
+	require.Nil(err)
+
+	var cts [3][]byte
+	for i := 0; i < 3; i++ {
+		cts[i], err = s.Send(alice, msg, msg)
+		require.Nil(err)
+	}
+
+	// Alice evolves her keys past the last XMSS period.
+	for i := 0; i < 2*x.MaxPeriod(); i++ {
+		ct, err := s.Send(bob, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(alice, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 3; i++ {
+		pt, err := s.Receive(bob, msg, cts[i])
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 3; i++ {
+		ct, err := s.Send(alice, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(bob, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		ct, err = s.Send(bob, msg, msg)
+		require.Nil(err)
+
+		pt, err = s.Receive(alice, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
+
+func TestSCh_MMM(t *testing.T) {
+	require := require.New(t)
+
+	ed := signature.NewEd25519()
+	p := signature.NewProduct(signature.NewSum(ed, 1), signature.NewSum(ed, 1))
+	s := NewSCh(p, hibe.NewGentry())
+
+	alice, bob, err := s.Init()
+	require.Nil(err)
+
+	var cts [3][]byte
+	for i := 0; i < 3; i++ {
+		cts[i], err = s.Send(alice, msg, msg)
+		require.Nil(err)
+	}
+
+	// Alice evolves her keys past the last period of the product composition.
+	for i := 0; i < 2*p.MaxPeriod(); i++ {
+		ct, err := s.Send(bob, msg, msg)
+		require.Nil(err)
+
+		pt, err := s.Receive(alice, msg, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 3; i++ {
+		pt, err := s.Receive(bob, msg, cts[i])
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
@@ -16,9 +16,13 @@ var (
 	ecdsa  = signature.NewECDSA(curve)
 	gentry = hibe.NewGentry()
 
-	brke = pr.NewBRKE(gentry, ecdsa)
+	brke    = pr.NewBRKE(gentry, ecdsa)
+	bounded = pr.NewBoundedBRKE(gentry, ecdsa, window)
 )
 
+// window is the epoch window of the bounded protocol instance.
+const window = 50
+
 var (
 	msg = []byte("msg")
 	ad  = []byte("ad")
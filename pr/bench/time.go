@@ -6,6 +6,8 @@ package main
 import (
 	"fmt"
 	"testing"
+
+	"github.com/qantik/ratcheted/pr"
 )
 
 func time_alt(n int) {
@@ -39,26 +41,57 @@ func time_uni(n int) {
 }
 
 func time_def(n int) {
+	deferred(brke, n)
+}
+
+func time_def_bounded(n int) {
+	refused[n] = deferred(bounded, n)
+}
+
+// refused holds the number of sends of the last run of a bounded scenario per number of
+// messages that have been refused because they would exceed the epoch window. Those runs
+// perform fewer operations than the unbounded ones and are not directly comparable.
+var refused = map[int]int{}
+
+// deferred runs the deferred unidirectional scenario and returns the number of sends that
+// are refused because they would exceed the epoch window of a bounded protocol instance.
+// Refused messages are skipped.
+func deferred(brke *pr.BRKE, n int) int {
 	alice, bob, _ := brke.Init()
 
+	r := 0
+
 	var ks [1000][]byte
 	var cs [1000][][]byte
 	for i := 0; i < n/2; i++ {
-		k, c, _ := brke.Send(alice, ad)
+		k, c, err := brke.Send(alice, ad)
+		if err == pr.ErrEpochWindow {
+			r++
+			continue
+		}
 		ks[i] = k
 		cs[i] = c
 	}
 
 	for i := 0; i < n/2; i++ {
-		kb, c, _ := brke.Send(bob, ad)
+		kb, c, err := brke.Send(bob, ad)
+		if err == pr.ErrEpochWindow {
+			r++
+			continue
+		}
 		ka, _ := brke.Receive(alice, ad, c)
 		_, _ = ka, kb
 	}
 
 	for i := 0; i < n/2; i++ {
+		if cs[i] == nil {
+			continue
+		}
 		k, _ := brke.Receive(bob, ad, cs[i])
 		_ = k
 	}
+
+	return r
 }
 
 // func benchmarkAlt(i int, b *testing.B) {
@@ -80,7 +113,7 @@ func time_def(n int) {
 // }
 
 func time(tp func(i int)) {
-	s := ""
+	s, r := "", ""
 	for _, i := range []int{50, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200} {
 		fn := func(b *testing.B) {
 			for n := 0; n < b.N; n++ {
@@ -90,8 +123,14 @@ func time(tp func(i int)) {
 		res := testing.Benchmark(fn)
 
 		s += fmt.Sprintf("(%d,%.4f)", i, float64(res.T)/1000000000.0/float64(res.N))
+		if n, ok := refused[i]; ok {
+			r += fmt.Sprintf("(%d,%d)", i, n)
+		}
 	}
 	fmt.Println(s)
+	if r != "" {
+		fmt.Println("refused sends:", r)
+	}
 }
 
 // func BenchmarkAlt50(b *testing.B)  { benchmarkAlt(50, b) }
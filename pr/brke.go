@@ -12,8 +12,10 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/sha512"
+	"sort"
 	"strconv"
 
+	"github.com/alecthomas/binary"
 	"github.com/pkg/errors"
 
 	"github.com/qantik/ratcheted/primitives"
@@ -27,11 +29,22 @@ const (
 	sessionKeySize  = 16
 )
 
+// ErrEpochWindow is returned by Send if the message would exceed the maximum number of
+// unacknowledged epochs. The user state is left untouched such that sending can be
+// resumed once a message of the other user has been received. It is returned by Receive
+// if the other user has exceeded the window.
+var ErrEpochWindow = errors.New("maximum number of unacknowledged epochs exceeded")
+
 // BRKE designates the PT18 protocol object defined by a ku-KEM scheme and a
 // one-time signature algorithm.
 type BRKE struct {
 	kuKEM     *kuKEM
 	signature signature.Signature
+
+	// window is the maximum number of active epochs beyond the oldest one. It bounds the
+	// number of ku-KEM encapsulations per sent message and the number of ku-KEM secret
+	// keys a user has to store and update. A value of zero designates no limit.
+	window int
 }
 
 // User designates a participant in the protocol that can both send and receive
@@ -87,6 +100,16 @@ func NewBRKE(hibe hibe.HIBE, signature signature.Signature) *BRKE {
 	return &BRKE{kuKEM: &kuKEM{hibe: hibe}, signature: signature}
 }
 
+// NewBoundedBRKE creates a fresh BRKE protocol instance in which a user can only be
+// window epochs ahead of the last epoch acknowledged by the other user. It panics if the
+// window is not positive.
+func NewBoundedBRKE(hibe hibe.HIBE, signature signature.Signature, window int) *BRKE {
+	if window <= 0 {
+		panic("pr: epoch window has to be positive")
+	}
+	return &BRKE{kuKEM: &kuKEM{hibe: hibe}, signature: signature, window: window}
+}
+
 // Init creates two fresh users objects that can communicate with each other.
 func (b BRKE) Init() (*User, *User, error) {
 	// Generate two sets of signature key pairs.
@@ -177,6 +200,10 @@ func oracle(Ks, ks, ts []byte) ([]byte, []byte, []byte) {
 // Send creates a new session key and a corresponding ciphertext that has to be passed
 // to the other user in order to notify him of the update.
 func (b BRKE) Send(user *User, ad []byte) ([]byte, [][]byte, error) {
+	if b.window > 0 && user.r.E1+1-user.r.E0 > b.window {
+		return nil, nil, ErrEpochWindow
+	}
+
 	// Generate new signature and ku-KEM key pairs. Store the signing key and append
 	// the two public keys to the ciphertext.
 	vfks, sgks, err := b.signature.Generate()
@@ -226,8 +253,10 @@ func (b BRKE) Send(user *User, ad []byte) ([]byte, [][]byte, error) {
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "error while generating kem keys")
 	}
-	for i := 0; i < user.s.E1; i++ {
-		user.s.PK[i] = nil
+	for i := range user.s.PK {
+		if i < user.s.E1 {
+			delete(user.s.PK, i)
+		}
 	}
 	user.s.PK[user.s.E1] = pk
 	user.s.E0 = user.s.E1
@@ -240,6 +269,15 @@ func (b BRKE) Send(user *User, ad []byte) ([]byte, [][]byte, error) {
 
 // Receive receives a newly established session key created by the opposing user.
 func (b BRKE) Receive(user *User, ad []byte, C [][]byte) ([]byte, error) {
+	// A ciphertext consists of at least four header fields, one encapsulation and
+	// a signature.
+	if len(C) < 6 {
+		return nil, errors.New("malformed ciphertext")
+	}
+	if b.window > 0 && user.s.E1+1-user.s.E0 > b.window {
+		return nil, ErrEpochWindow
+	}
+
 	// Update s-transcript.
 	ts := append(ad, bytes.Join(C, nil)...)
 	user.s.t = append(user.s.t, ts...)
@@ -257,8 +295,10 @@ func (b BRKE) Receive(user *User, ad []byte, C [][]byte) ([]byte, error) {
 	if err != nil || user.s.L[rr] == nil {
 		return nil, errors.New("users are out-of-sync")
 	}
-	for i := 0; i < rr; i++ {
-		user.s.L[i] = nil
+	for i := range user.s.L {
+		if i < rr {
+			delete(user.s.L, i)
+		}
 	}
 	user.s.L[rr] = []byte{}
 
@@ -275,14 +315,16 @@ func (b BRKE) Receive(user *User, ad []byte, C [][]byte) ([]byte, error) {
 	// Check that received epoch is still active and delete old ciphertexts.
 	e, _ := strconv.Atoi(string(C[0]))
 	C = C[1:]
-	if e < user.r.E0 || e > user.r.E1 {
+	if e < user.r.E0 || e > user.r.E1 || len(C) != e-user.r.E0+1 {
 		return nil, errors.New("users are out-of-sync")
 	}
 	for i := user.r.E0 + 1; i <= e; i++ {
 		user.r.t = append(user.r.t, user.r.L[i]...)
 	}
-	for i := 0; i <= e; i++ {
-		user.r.L[i] = nil
+	for i := range user.r.L {
+		if i <= e {
+			delete(user.r.L, i)
+		}
 	}
 
 	// Recreate hashing key and poll oracle to establish the same session key, chaining key
@@ -308,8 +350,10 @@ func (b BRKE) Receive(user *User, ad []byte, C [][]byte) ([]byte, error) {
 	}
 
 	// Delete old ku-KEM secret keys and update those which are still active.
-	for i := 0; i <= e-1; i++ {
-		user.r.SK[i] = nil
+	for i := range user.r.SK {
+		if i < e {
+			delete(user.r.SK, i)
+		}
 	}
 	user.r.SK[e] = sk
 	for i := e + 1; i <= user.r.E1; i++ {
@@ -326,6 +370,135 @@ func (b BRKE) Receive(user *User, ad []byte, C [][]byte) ([]byte, error) {
 	return ko, nil
 }
 
+// userVersion is the version of the user encoding produced by MarshalBinary.
+const userVersion = 1
+
+// userPacket is a helper structure that enables marshalling.
+type userPacket struct {
+	Version int
+	Name    string
+
+	R rPacket
+	S sPacket
+}
+
+// rPacket is a helper structure that enables marshalling of a r sub-state.
+type rPacket struct {
+	SK, L     []epochPacket
+	E0, E1, R int
+	Sgk, K, T []byte
+}
+
+// sPacket is a helper structure that enables marshalling of a s sub-state.
+type sPacket struct {
+	PK, L     []epochPacket
+	E0, E1, S int
+	Vfk, K, T []byte
+}
+
+// epochPacket is a helper structure that enables marshalling of epoch-indexed maps.
+type epochPacket struct {
+	I int
+	V []byte
+}
+
+// MarshalBinary encodes the complete state of a user including both sub-states such that
+// a session can be resumed later on. Note, that a restored user can only be used with a
+// BRKE instance that has been created with the same HIBE and signature schemes.
+func (u User) MarshalBinary() ([]byte, error) {
+	packet := &userPacket{
+		Version: userVersion,
+		Name:    u.name,
+		R: rPacket{
+			SK: encodeEpochs(u.r.SK), L: encodeEpochs(u.r.L),
+			E0: u.r.E0, E1: u.r.E1, R: u.r.r,
+			Sgk: u.r.sgk, K: u.r.K, T: u.r.t,
+		},
+		S: sPacket{
+			PK: encodeEpochs(u.s.PK), L: encodeEpochs(u.s.L),
+			E0: u.s.E0, E1: u.s.E1, S: u.s.s,
+			Vfk: u.s.vfk, K: u.s.K, T: u.s.t,
+		},
+	}
+	return binary.Marshal(packet)
+}
+
+// UnmarshalBinary restores the state of a user encoded by MarshalBinary.
+func (u *User) UnmarshalBinary(data []byte) error {
+	var packet userPacket
+	if err := binary.Unmarshal(data, &packet); err != nil {
+		return errors.Wrap(err, "unable to decode user")
+	}
+	if packet.Version != userVersion {
+		return errors.Errorf("unsupported user encoding version %d", packet.Version)
+	}
+	if packet.Name != "alice" && packet.Name != "bob" {
+		return errors.Errorf("unknown user %q", packet.Name)
+	}
+	if packet.R.E0 < 0 || packet.R.E0 > packet.R.E1 || packet.S.E0 < 0 || packet.S.E0 > packet.S.E1 {
+		return errors.New("inconsistent user state")
+	}
+	if len(packet.R.Sgk) == 0 || len(packet.R.K) == 0 || len(packet.S.Vfk) == 0 || len(packet.S.K) == 0 {
+		return errors.New("incomplete user state")
+	}
+
+	rs := &r{
+		SK: decodeEpochs(packet.R.SK), L: decodeEpochs(packet.R.L),
+		E0: packet.R.E0, E1: packet.R.E1, r: packet.R.R,
+		sgk: packet.R.Sgk, K: packet.R.K, t: packet.R.T,
+	}
+	ss := &s{
+		PK: decodeEpochs(packet.S.PK), L: decodeEpochs(packet.S.L),
+		E0: packet.S.E0, E1: packet.S.E1, s: packet.S.S,
+		vfk: packet.S.Vfk, K: packet.S.K, t: packet.S.T,
+	}
+
+	// The protocol looks up the ku-KEM keys of all active epochs, the ciphertexts of
+	// the active r-epochs after the oldest one and the ciphertext of the last sent message.
+	if len(rs.SK) != rs.E1-rs.E0+1 || len(rs.L) != rs.E1-rs.E0 || len(ss.PK) != ss.E1-ss.E0+1 {
+		return errors.New("incomplete user state")
+	}
+	for i := rs.E0; i <= rs.E1; i++ {
+		if _, ok := rs.SK[i]; !ok {
+			return errors.Errorf("missing ku-kem secret key of epoch %d", i)
+		}
+		if _, ok := rs.L[i]; !ok && i > rs.E0 {
+			return errors.Errorf("missing ciphertext of epoch %d", i)
+		}
+	}
+	for i := ss.E0; i <= ss.E1; i++ {
+		if _, ok := ss.PK[i]; !ok {
+			return errors.Errorf("missing ku-kem public key of epoch %d", i)
+		}
+	}
+	if _, ok := ss.L[ss.s]; !ok {
+		return errors.Errorf("missing ciphertext of message %d", ss.s)
+	}
+
+	u.name, u.r, u.s = packet.Name, rs, ss
+	return nil
+}
+
+// encodeEpochs flattens an epoch-indexed map into a list sorted by epoch.
+func encodeEpochs(m map[int][]byte) []epochPacket {
+	packets := make([]epochPacket, 0, len(m))
+	for i, v := range m {
+		packets = append(packets, epochPacket{I: i, V: v})
+	}
+	sort.Slice(packets, func(i, j int) bool { return packets[i].I < packets[j].I })
+	return packets
+}
+
+// decodeEpochs restores an epoch-indexed map. Entries are never nil after decoding since
+// the presence of an entry is meaningful to the protocol.
+func decodeEpochs(packets []epochPacket) map[int][]byte {
+	m := make(map[int][]byte, len(packets))
+	for _, p := range packets {
+		m[p.I] = append([]byte{}, p.V...)
+	}
+	return m
+}
+
 // Size returns the size (in bytes) of the user state.
 func (u User) Size() int {
 	size := 0
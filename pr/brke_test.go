@@ -6,10 +6,15 @@ package pr
 import (
 	"bytes"
 	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"io/ioutil"
 	"testing"
 
+	"github.com/alecthomas/binary"
 	"github.com/stretchr/testify/require"
 
+	"github.com/qantik/ratcheted/primitives/encryption"
 	"github.com/qantik/ratcheted/primitives/hibe"
 	"github.com/qantik/ratcheted/primitives/signature"
 )
@@ -118,3 +123,308 @@ func TestBRKE_DefUnidirectional(t *testing.T) {
 		require.True(bytes.Equal(ks[i], k))
 	}
 }
+
+func TestBRKE_Window(t *testing.T) {
+	require := require.New(t)
+
+	brke := NewBoundedBRKE(hibe.NewGentry(), signature.NewECDSA(curve), 3)
+
+	alice, bob, err := brke.Init()
+	require.Nil(err)
+
+	var ks [3][]byte
+	var cs [3][][]byte
+	for i := 0; i < 3; i++ {
+		k, c, err := brke.Send(alice, ad)
+		require.Nil(err)
+
+		ks[i] = k
+		cs[i] = c
+	}
+
+	_, _, err = brke.Send(alice, ad)
+	require.Equal(ErrEpochWindow, err)
+
+	for i := 0; i < 3; i++ {
+		k, err := brke.Receive(bob, ad, cs[i])
+		require.Nil(err)
+		require.True(bytes.Equal(ks[i], k))
+	}
+
+	kb, c, err := brke.Send(bob, ad)
+	require.Nil(err)
+
+	ka, err := brke.Receive(alice, ad, c)
+	require.Nil(err)
+	require.True(bytes.Equal(ka, kb))
+
+	for i := 0; i < 3; i++ {
+		ka, c, err := brke.Send(alice, ad)
+		require.Nil(err)
+
+		kb, err := brke.Receive(bob, ad, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+	}
+
+	require.Panics(func() { NewBoundedBRKE(hibe.NewGentry(), signature.NewECDSA(curve), 0) })
+}
+
+func TestBRKE_Restore(t *testing.T) {
+	require := require.New(t)
+
+	brke := NewBRKE(hibe.NewGentry(), signature.NewECDSA(curve))
+
+	alice, bob, err := brke.Init()
+	require.Nil(err)
+
+	restore := func(u *User) *User {
+		data, err := u.MarshalBinary()
+		require.Nil(err)
+
+		var r User
+		require.Nil(r.UnmarshalBinary(data))
+		require.Equal(u.Size(), r.Size())
+		return &r
+	}
+
+	for i := 0; i < 3; i++ {
+		ka, c, err := brke.Send(alice, ad)
+		require.Nil(err)
+		bob = restore(bob)
+
+		kb, err := brke.Receive(bob, ad, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+		alice, bob = restore(alice), restore(bob)
+
+		kb, c, err = brke.Send(bob, ad)
+		require.Nil(err)
+
+		ka, err = brke.Receive(alice, ad, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+		alice = restore(alice)
+	}
+
+	var ks [5][]byte
+	var cs [5][][]byte
+	for i := 0; i < 5; i++ {
+		k, c, err := brke.Send(alice, ad)
+		require.Nil(err)
+		alice = restore(alice)
+
+		ks[i] = k
+		cs[i] = c
+	}
+
+	for i := 0; i < 5; i++ {
+		kb, c, err := brke.Send(bob, ad)
+		require.Nil(err)
+		bob = restore(bob)
+
+		ka, err := brke.Receive(alice, ad, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+		alice = restore(alice)
+	}
+
+	for i := 0; i < 5; i++ {
+		k, err := brke.Receive(bob, ad, cs[i])
+		require.Nil(err)
+		require.True(bytes.Equal(ks[i], k))
+		bob = restore(bob)
+	}
+
+	ka, c, err := brke.Send(alice, ad)
+	require.Nil(err)
+
+	kb, err := brke.Receive(bob, ad, c)
+	require.Nil(err)
+	require.True(bytes.Equal(ka, kb))
+}
+
+func TestBRKE_RestoreVersion(t *testing.T) {
+	require := require.New(t)
+
+	alice, _, err := brke.Init()
+	require.Nil(err)
+
+	data, err := alice.MarshalBinary()
+	require.Nil(err)
+
+	var packet userPacket
+	require.Nil(binary.Unmarshal(data, &packet))
+	packet.Version = userVersion + 1
+	data, err = binary.Marshal(&packet)
+	require.Nil(err)
+
+	var u User
+	require.NotNil(u.UnmarshalBinary(data))
+}
+
+func TestBRKE_RestoreInvalid(t *testing.T) {
+	require := require.New(t)
+
+	alice, bob, err := brke.Init()
+	require.Nil(err)
+
+	// Alice has two active r-epochs after sending twice.
+	for i := 0; i < 2; i++ {
+		_, c, err := brke.Send(alice, ad)
+		require.Nil(err)
+		_, err = brke.Receive(bob, ad, c)
+		require.Nil(err)
+	}
+
+	data, err := alice.MarshalBinary()
+	require.Nil(err)
+
+	for _, corrupt := range []func(p *userPacket){
+		func(p *userPacket) { p.Name = "eve" },
+		func(p *userPacket) { p.R.SK = p.R.SK[1:] },
+		func(p *userPacket) { p.R.SK[1].I = p.R.SK[0].I },
+		func(p *userPacket) { p.R.L = p.R.L[:1] },
+		func(p *userPacket) { p.R.E1 += 1 },
+		func(p *userPacket) { p.S.PK = nil },
+		func(p *userPacket) { p.S.L = nil },
+		func(p *userPacket) { p.R.Sgk = nil },
+		func(p *userPacket) { p.S.Vfk = nil },
+		func(p *userPacket) { p.R.K = nil },
+		func(p *userPacket) { p.S.K = nil },
+	} {
+		var packet userPacket
+		require.Nil(binary.Unmarshal(data, &packet))
+		corrupt(&packet)
+		d, err := binary.Marshal(&packet)
+		require.Nil(err)
+
+		var u User
+		require.NotNil(u.UnmarshalBinary(d))
+	}
+
+	var u User
+	require.Nil(u.UnmarshalBinary(data))
+}
+
+func TestBRKE_Stream(t *testing.T) {
+	require := require.New(t)
+
+	alice, bob, err := brke.Init()
+	require.Nil(err)
+
+	ka, c, err := brke.Send(alice, ad)
+	require.Nil(err)
+
+	// Encrypt a large payload under the session key while only c goes over the ratchet.
+	payload := make([]byte, 3*encryption.StreamSegmentSize+10)
+	rand.Read(payload)
+
+	var buf bytes.Buffer
+	w, err := encryption.NewStreamWriter(&buf, ka, ad)
+	require.Nil(err)
+	_, err = w.Write(payload)
+	require.Nil(err)
+	require.Nil(w.Close())
+
+	kb, err := brke.Receive(bob, ad, c)
+	require.Nil(err)
+
+	r, err := encryption.NewStreamReader(&buf, kb, ad)
+	require.Nil(err)
+	pt, err := ioutil.ReadAll(r)
+	require.Nil(err)
+	require.True(bytes.Equal(payload, pt))
+}
+
+func TestBRKE_EdDSA(t *testing.T) {
+	require := require.New(t)
+
+	for _, sig := range []signature.Signature{signature.NewEd25519(), signature.NewEd448()} {
+		brke := NewBRKE(hibe.NewGentry(), sig)
+
+		alice, bob, err := brke.Init()
+		require.Nil(err)
+
+		for i := 0; i < 5; i++ {
+			ka, c, err := brke.Send(alice, ad)
+			require.Nil(err)
+
+			kb, err := brke.Receive(bob, ad, c)
+			require.Nil(err)
+			require.True(bytes.Equal(ka, kb))
+
+			kb, c, err = brke.Send(bob, ad)
+			require.Nil(err)
+
+			ka, err = brke.Receive(alice, ad, c)
+			require.Nil(err)
+			require.True(bytes.Equal(ka, kb))
+		}
+	}
+}
+
+func TestBRKE_OneTime(t *testing.T) {
+	require := require.New(t)
+
+	brke := NewBRKE(hibe.NewGentry(), signature.NewWOTS(rand.Reader, sha256.New, 16))
+
+	alice, bob, err := brke.Init()
+	require.Nil(err)
+
+	var cs [3][][]byte
+	for i := 0; i < 3; i++ {
+		_, cs[i], err = brke.Send(alice, ad)
+		require.Nil(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := brke.Receive(bob, ad, cs[i])
+		require.Nil(err)
+
+		kb, c, err := brke.Send(bob, ad)
+		require.Nil(err)
+
+		ka, err := brke.Receive(alice, ad, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+	}
+}
+
+func TestBRKE_OneTimeRestore(t *testing.T) {
+	require := require.New(t)
+
+	wots := signature.NewWOTS(rand.Reader, sha256.New, 16)
+	brke := NewBRKE(hibe.NewGentry(), wots)
+
+	alice, bob, err := brke.Init()
+	require.Nil(err)
+
+	restore := func(u *User) *User {
+		data, err := u.MarshalBinary()
+		require.Nil(err)
+
+		var r User
+		require.Nil(r.UnmarshalBinary(data))
+		return &r
+	}
+
+	for i := 0; i < 3; i++ {
+		used := alice.r.sgk
+
+		ka, c, err := brke.Send(alice, ad)
+		require.Nil(err)
+
+		// The per-message signing key is erased and the restored user signs the next
+		// message with its replacement.
+		_, err = wots.Sign(used, ad)
+		require.Equal(signature.ErrKeyUsed, err)
+
+		alice = restore(alice)
+		require.NotEqual(used, alice.r.sgk)
+
+		kb, err := brke.Receive(bob, ad, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+	}
+}
@@ -0,0 +1,76 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package pr
+
+import (
+	"github.com/alecthomas/binary"
+	"github.com/pkg/errors"
+
+	"github.com/qantik/ratcheted/primitives/encryption"
+)
+
+// Channel designates a messaging protocol that encrypts each message with an
+// authenticated encryption scheme under a fresh BRKE session key.
+type Channel struct {
+	brke *BRKE
+	aead encryption.Authenticated
+}
+
+// channelMessage bundles the BRKE ciphertext and the encrypted payload.
+type channelMessage struct {
+	C  [][]byte // C is the BRKE ciphertext establishing the session key.
+	CT []byte   // CT is the payload encrypted under the session key.
+}
+
+// NewChannel creates a fresh channel instance for a given BRKE protocol and an
+// authenticated encryption scheme that accepts the 16-byte BRKE session keys.
+func NewChannel(brke *BRKE, aead encryption.Authenticated) *Channel {
+	return &Channel{brke: brke, aead: aead}
+}
+
+// Init creates two fresh user objects that can communicate with each other.
+func (c Channel) Init() (*User, *User, error) {
+	return c.brke.Init()
+}
+
+// Send ratchets the BRKE state of a user forward and encrypts a message with the
+// established session key. The associated data is authenticated by both the BRKE
+// ciphertext and the encrypted payload.
+func (c Channel) Send(user *User, ad, msg []byte) ([]byte, error) {
+	k, C, err := c.brke.Send(user, ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to establish session key")
+	}
+
+	ct, err := c.aead.Encrypt(k, msg, ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encrypt message")
+	}
+
+	m, err := binary.Marshal(&channelMessage{C: C, CT: ct})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode message")
+	}
+	return m, nil
+}
+
+// Receive recovers the session key established by the other user and uses it to
+// decrypt and authenticate the payload.
+func (c Channel) Receive(user *User, ad, ct []byte) ([]byte, error) {
+	var m channelMessage
+	if err := binary.Unmarshal(ct, &m); err != nil {
+		return nil, errors.Wrap(err, "unable to decode message")
+	}
+
+	k, err := c.brke.Receive(user, ad, m.C)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to recover session key")
+	}
+
+	msg, err := c.aead.Decrypt(k, m.CT, ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decrypt message")
+	}
+	return msg, nil
+}
@@ -0,0 +1,88 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package pr
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives/encryption"
+	"github.com/qantik/ratcheted/primitives/hibe"
+	"github.com/qantik/ratcheted/primitives/signature"
+)
+
+var msg = []byte("channel")
+
+func TestChannel_Alternating(t *testing.T) {
+	require := require.New(t)
+
+	c := NewChannel(NewBRKE(hibe.NewGentry(), signature.NewECDSA(curve)), encryption.NewGCM())
+
+	alice, bob, err := c.Init()
+	require.Nil(err)
+
+	for i := 0; i < 5; i++ {
+		ct, err := c.Send(alice, ad, msg)
+		require.Nil(err)
+
+		pt, err := c.Receive(bob, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		ct, err = c.Send(bob, ad, msg)
+		require.Nil(err)
+
+		pt, err = c.Receive(alice, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
+
+func TestChannel_DefUnidirectional(t *testing.T) {
+	require := require.New(t)
+
+	c := NewChannel(NewBRKE(hibe.NewGentry(), signature.NewECDSA(curve)), encryption.NewGCM())
+
+	alice, bob, err := c.Init()
+	require.Nil(err)
+
+	var cts [5][]byte
+	for i := 0; i < 5; i++ {
+		ct, err := c.Send(alice, ad, msg)
+		require.Nil(err)
+		cts[i] = ct
+	}
+
+	for i := 0; i < 5; i++ {
+		ct, err := c.Send(bob, ad, msg)
+		require.Nil(err)
+
+		pt, err := c.Receive(alice, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+
+	for i := 0; i < 5; i++ {
+		pt, err := c.Receive(bob, ad, cts[i])
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+	}
+}
+
+func TestChannel_AssociatedData(t *testing.T) {
+	require := require.New(t)
+
+	c := NewChannel(NewBRKE(hibe.NewGentry(), signature.NewECDSA(curve)), encryption.NewGCM())
+
+	alice, bob, err := c.Init()
+	require.Nil(err)
+
+	ct, err := c.Send(alice, ad, msg)
+	require.Nil(err)
+
+	_, err = c.Receive(bob, []byte("ad"), ct)
+	require.NotNil(err)
+}
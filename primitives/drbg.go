@@ -0,0 +1,62 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package primitives
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// drbgChunkSize is the maximum number of bytes a single HKDF-SHA256 instance can output.
+const drbgChunkSize = 255 * sha256.Size
+
+// drbg is a deterministic random bit generator based on HKDF-SHA256. Since the output of
+// a HKDF instance is bounded, the stream is composed of consecutive chunks which are
+// separated by a counter in the info parameter.
+type drbg struct {
+	seed, label []byte
+	counter     uint64
+	chunk       io.Reader
+	remaining   int
+}
+
+// NewDRBG returns a reader that expands a seed into an unbounded stream of pseudo-random
+// bytes. The label separates the streams that are derived from the same seed for different
+// purposes. If seed is nil crypto/rand is returned instead.
+func NewDRBG(seed []byte, label string) io.Reader {
+	if seed == nil {
+		return rand.Reader
+	}
+	return &drbg{seed: seed, label: []byte(label)}
+}
+
+// Read fills p with the next bytes of the stream.
+func (d *drbg) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if d.remaining == 0 {
+			var counter [8]byte
+			binary.BigEndian.PutUint64(counter[:], d.counter)
+			d.counter++
+
+			d.chunk = hkdf.New(sha256.New, d.seed, nil, Concat(d.label, counter[:]))
+			d.remaining = drbgChunkSize
+		}
+
+		m := len(p) - n
+		if m > d.remaining {
+			m = d.remaining
+		}
+		if _, err := io.ReadFull(d.chunk, p[n:n+m]); err != nil {
+			return n, err
+		}
+		d.remaining -= m
+		n += m
+	}
+	return n, nil
+}
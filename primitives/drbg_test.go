@@ -0,0 +1,37 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package primitives
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDRBG(t *testing.T) {
+	require := require.New(t)
+
+	read := func(r io.Reader, n int) []byte {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		require.Nil(err)
+		return b
+	}
+
+	seed := []byte("seed")
+
+	a, b := NewDRBG(seed, "label"), NewDRBG(seed, "label")
+	require.True(bytes.Equal(read(a, 100000), read(b, 100000)))
+
+	// The stream must not depend on how it is consumed.
+	a, b = NewDRBG(seed, "label"), NewDRBG(seed, "label")
+	x := append(read(a, 7), read(a, 93)...)
+	require.True(bytes.Equal(x, read(b, 100)))
+
+	require.False(bytes.Equal(read(NewDRBG(seed, "label"), 32), read(NewDRBG(seed, "other"), 32)))
+	require.False(bytes.Equal(read(NewDRBG(seed, "label"), 32), read(NewDRBG([]byte("deed"), "label"), 32)))
+	require.False(bytes.Equal(read(NewDRBG(nil, "label"), 32), read(NewDRBG(nil, "label"), 32)))
+}
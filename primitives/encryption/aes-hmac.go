@@ -0,0 +1,102 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// AESHMAC implements an encrypt-then-mac scheme composed of AES-CTR and HMAC-SHA256.
+// Independent encryption and authentication keys are derived from the symmetric key
+// with HKDF. The IV is prepended and the tag is appended to the ciphertext.
+type AESHMAC struct{}
+
+// NewAESHMAC returns a fresh AES-CTR/HMAC-SHA256 instance.
+func NewAESHMAC() *AESHMAC {
+	return &AESHMAC{}
+}
+
+// Generate creates a fresh 16-byte symmetric key. If seed is nil crypto.rand is used as
+// the random stream, otherwise the seed is expanded deterministically.
+func (a AESHMAC) Generate(seed []byte) ([]byte, error) {
+	k := make([]byte, aesKeySize)
+
+	if _, err := io.ReadFull(primitives.NewDRBG(seed, "aes-hmac"), k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Encrypt enciphers a message under a given key and authenticates the ciphertext.
+func (a AESHMAC) Encrypt(key, msg []byte) ([]byte, error) {
+	ke, km, err := a.derive(key)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(ke)
+	if err != nil {
+		return nil, err
+	}
+
+	ct := make([]byte, aes.BlockSize+len(msg))
+	iv := ct[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	ctr := cipher.NewCTR(block, iv)
+	ctr.XORKeyStream(ct[aes.BlockSize:], msg)
+
+	return append(ct, primitives.Digest(hmac.New(sha256.New, km), ct)...), nil
+}
+
+// Decrypt verifies the tag of a ciphertext and deciphers it under a given key.
+func (a AESHMAC) Decrypt(key, ct []byte) ([]byte, error) {
+	if len(ct) < aes.BlockSize+sha256.Size {
+		return nil, fmt.Errorf("invalid ciphertext size: %v", len(ct))
+	}
+
+	ke, km, err := a.derive(key)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(ct) - sha256.Size
+	tau := primitives.Digest(hmac.New(sha256.New, km), ct[:n])
+	if !hmac.Equal(tau, ct[n:]) {
+		return nil, errors.New("failed to verify mac")
+	}
+
+	block, err := aes.NewCipher(ke)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, n-aes.BlockSize)
+	ctr := cipher.NewCTR(block, ct[:aes.BlockSize])
+	ctr.XORKeyStream(msg, ct[aes.BlockSize:n])
+
+	return msg, nil
+}
+
+// derive splits a symmetric key into an encryption and an authentication key.
+func (a AESHMAC) derive(key []byte) (ke, km []byte, err error) {
+	k := make([]byte, aesKeySize+sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte("aes-hmac")), k); err != nil {
+		return nil, nil, err
+	}
+	return k[:aesKeySize], k[aesKeySize:], nil
+}
@@ -0,0 +1,39 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESHMAC(t *testing.T) {
+	require := require.New(t)
+
+	aes := NewAESHMAC()
+
+	k, err := aes.Generate(nil)
+	require.Nil(err)
+
+	for _, msg := range [][]byte{[]byte("aes-hmac"), {}} {
+		ct, err := aes.Encrypt(k, msg)
+		require.Nil(err)
+
+		pt, err := aes.Decrypt(k, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		for i := range ct {
+			ct[i] ^= 1
+			_, err = aes.Decrypt(k, ct)
+			require.NotNil(err)
+			ct[i] ^= 1
+		}
+	}
+
+	_, err = aes.Decrypt(k, make([]byte, 47))
+	require.NotNil(err)
+}
@@ -8,7 +8,11 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
+	"fmt"
 	"io"
+
+	"github.com/qantik/ratcheted/primitives"
 )
 
 // aesKeySize designates the fixed 128-bit AES key size.
@@ -23,18 +27,12 @@ func NewAES() *AES {
 }
 
 // Generate creates a fresh 16-byte AES-CBC symmetric key. If seed is nil
-// crypto.rand is used as the random stream.
+// crypto.rand is used as the random stream, otherwise the seed is expanded
+// deterministically.
 func (a AES) Generate(seed []byte) ([]byte, error) {
-	var reader io.Reader
-	if seed == nil {
-		reader = rand.Reader
-	} else {
-		reader = bytes.NewReader(seed)
-	}
-
 	k := make([]byte, aesKeySize)
 
-	if _, err := io.ReadFull(reader, k); err != nil {
+	if _, err := io.ReadFull(primitives.NewDRBG(seed, "aes"), k); err != nil {
 		return nil, err
 	}
 	return k, nil
@@ -62,32 +60,49 @@ func (a AES) Encrypt(key, msg []byte) ([]byte, error) {
 	return ct, nil
 }
 
-// Decrypt invokes the AES-CBC decryption routine.
+// Decrypt invokes the AES-CBC decryption routine. Note, that the ciphertext is not
+// authenticated, use AESHMAC if integrity is required.
 func (a AES) Decrypt(key, ct []byte) ([]byte, error) {
+	if len(ct) < 2*aes.BlockSize || len(ct)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid ciphertext size: %v", len(ct))
+	}
+
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
 
 	iv := ct[:aes.BlockSize]
-	ct = ct[aes.BlockSize:]
+	pt := make([]byte, len(ct)-aes.BlockSize)
 
 	cbc := cipher.NewCBCDecrypter(block, iv)
-	cbc.CryptBlocks(ct, ct)
+	cbc.CryptBlocks(pt, ct[aes.BlockSize:])
 
-	return unpad(ct), nil
+	return unpad(pt)
 }
 
 // pad a byte slice to a multiple of the AES block size.
 func pad(src []byte) []byte {
 	padding := aes.BlockSize - len(src)%aes.BlockSize
 	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(src, padtext...)
+	return append(append([]byte{}, src...), padtext...)
 }
 
-// unpad a byte slice.
-func unpad(src []byte) []byte {
+// unpad a byte slice and check that the padding is well-formed.
+func unpad(src []byte) ([]byte, error) {
 	length := len(src)
+	if length == 0 {
+		return nil, errors.New("invalid padding")
+	}
+
 	unpadding := int(src[length-1])
-	return src[:(length - unpadding)]
+	if unpadding == 0 || unpadding > aes.BlockSize || unpadding > length {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range src[length-unpadding:] {
+		if int(b) != unpadding {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return src[:(length - unpadding)], nil
 }
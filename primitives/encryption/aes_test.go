@@ -25,3 +25,24 @@ func TestAES(t *testing.T) {
 	require.Nil(t, err)
 	require.True(t, bytes.Equal(msg, pt))
 }
+
+func TestAES_Malformed(t *testing.T) {
+	aes := NewAES()
+
+	k, err := aes.Generate(nil)
+	require.Nil(t, err)
+
+	for _, ct := range [][]byte{nil, make([]byte, 15), make([]byte, 16), make([]byte, 33)} {
+		_, err = aes.Decrypt(k, ct)
+		require.NotNil(t, err)
+	}
+
+	ct, err := aes.Encrypt(k, []byte("aes-cbc"))
+	require.Nil(t, err)
+
+	// Flipping a bit in the IV corrupts the padding in the last block of a single
+	// block message.
+	ct[15] ^= 0xff
+	_, err = aes.Decrypt(k, ct)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,127 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ChaCha20Poly1305 implements the ChaCha20-Poly1305 AEAD scheme (RFC 8439). A random
+// 96-bit nonce is chosen for each encryption and prepended to the ciphertext.
+type ChaCha20Poly1305 struct{}
+
+// XChaCha20Poly1305 implements the XChaCha20-Poly1305 AEAD scheme. Its 192-bit nonce
+// is large enough to be chosen at random without a bound on the number of encryptions.
+type XChaCha20Poly1305 struct{}
+
+// NewChaCha20Poly1305 returns a fresh ChaCha20-Poly1305 instance.
+func NewChaCha20Poly1305() *ChaCha20Poly1305 {
+	return &ChaCha20Poly1305{}
+}
+
+// NewXChaCha20Poly1305 returns a fresh XChaCha20-Poly1305 instance.
+func NewXChaCha20Poly1305() *XChaCha20Poly1305 {
+	return &XChaCha20Poly1305{}
+}
+
+// Encrypt applies the ChaCha20-Poly1305 encryption/authentication routine to a given
+// message and associated data.
+func (c ChaCha20Poly1305) Encrypt(key, msg, ad []byte) ([]byte, error) {
+	k, err := chachaKey(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(k)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, msg, ad)
+}
+
+// Decrypt applies the ChaCha20-Poly1305 decryption routine to a given ciphertext and
+// associated data.
+func (c ChaCha20Poly1305) Decrypt(key, ct, ad []byte) ([]byte, error) {
+	k, err := chachaKey(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(k)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ct, ad)
+}
+
+// Encrypt applies the XChaCha20-Poly1305 encryption/authentication routine to a given
+// message and associated data.
+func (x XChaCha20Poly1305) Encrypt(key, msg, ad []byte) ([]byte, error) {
+	k, err := chachaKey(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(k)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, msg, ad)
+}
+
+// Decrypt applies the XChaCha20-Poly1305 decryption routine to a given ciphertext and
+// associated data.
+func (x XChaCha20Poly1305) Decrypt(key, ct, ad []byte) ([]byte, error) {
+	k, err := chachaKey(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(k)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ct, ad)
+}
+
+// chachaKey returns a 256-bit key. Only 128-bit and 256-bit keys are accepted. The
+// protocols partially operate on 128-bit keys, as they are shared with AES-GCM, hence
+// such keys are expanded with HKDF instead of being rejected.
+func chachaKey(key []byte) ([]byte, error) {
+	switch len(key) {
+	case chacha20poly1305.KeySize:
+		return key, nil
+	case 16:
+	default:
+		return nil, fmt.Errorf("invalid key size: %d", len(key))
+	}
+
+	k := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte("chacha20")), k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// seal encrypts a message under a random nonce which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, msg, ad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(msg)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, msg, ad), nil
+}
+
+// open decrypts a ciphertext that has been created by seal.
+func open(aead cipher.AEAD, ct, ad []byte) ([]byte, error) {
+	if len(ct) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("invalid ciphertext size: %v", len(ct))
+	}
+
+	n := aead.NonceSize()
+	return aead.Open(nil, ct[:n], ct[n:], ad)
+}
@@ -0,0 +1,80 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestChaCha20Poly1305(t *testing.T) {
+	require := require.New(t)
+
+	for _, aead := range []Authenticated{NewChaCha20Poly1305(), NewXChaCha20Poly1305()} {
+		for _, size := range []int{16, 32} {
+			key := make([]byte, size)
+
+			msg, ad := []byte("chacha20-poly1305"), []byte("associated-data")
+
+			ct, err := aead.Encrypt(key, msg, ad)
+			require.Nil(err)
+
+			pt, err := aead.Decrypt(key, ct, ad)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+
+			_, err = aead.Decrypt(key, ct, nil)
+			require.NotNil(err)
+
+			ct[len(ct)-1] ^= 1
+			_, err = aead.Decrypt(key, ct, ad)
+			require.NotNil(err)
+
+			_, err = aead.Decrypt(key, ct[:10], ad)
+			require.NotNil(err)
+		}
+
+		for _, size := range []int{0, 15, 24, 33} {
+			_, err := aead.Encrypt(make([]byte, size), []byte("msg"), nil)
+			require.NotNil(err)
+			_, err = aead.Decrypt(make([]byte, size), make([]byte, 64), nil)
+			require.NotNil(err)
+		}
+	}
+}
+
+// TestChaCha20Poly1305_RFC8439 checks the AEAD test vector of RFC 8439, Section 2.8.2.
+func TestChaCha20Poly1305_RFC8439(t *testing.T) {
+	require := require.New(t)
+
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.Nil(err)
+		return b
+	}
+
+	key := decode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	nonce := decode("070000004041424344454647")
+	ad := decode("50515253c0c1c2c3c4c5c6c7")
+	msg := []byte("Ladies and Gentlemen of the class of '99: If I could offer you " +
+		"only one tip for the future, sunscreen would be it.")
+	tag := decode("1ae10b594f09e26a7e902ecbd0600691")
+
+	aead, err := chacha20poly1305.New(key)
+	require.Nil(err)
+
+	ct := aead.Seal(nil, nonce, msg, ad)
+	require.True(bytes.Equal(tag, ct[len(ct)-len(tag):]))
+
+	chacha := NewChaCha20Poly1305()
+
+	ct = append(nonce, ct...)
+	pt, err := chacha.Decrypt(key, ct, ad)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+}
@@ -0,0 +1,194 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// hpkeVersion is the version label of all labeled HPKE key derivations.
+const hpkeVersion = "HPKE-v1"
+
+// DHKEM implements the Diffie-Hellman based key-encapsulation mechanism of RFC 9180
+// with HKDF-SHA256 as key derivation function.
+type DHKEM struct {
+	id    uint16     // id is the KEM identifier of RFC 9180, Section 7.1.
+	curve ecdh.Curve // curve is the underlying Diffie-Hellman group.
+	order *big.Int   // order is the group order of prime-order curves, nil for X25519.
+}
+
+// NewDHKEMX25519 creates a fresh DHKEM(X25519, HKDF-SHA256) instance.
+func NewDHKEMX25519() *DHKEM {
+	return &DHKEM{id: 0x0020, curve: ecdh.X25519()}
+}
+
+// NewDHKEMP256 creates a fresh DHKEM(P-256, HKDF-SHA256) instance.
+func NewDHKEMP256() *DHKEM {
+	n, _ := new(big.Int).SetString(
+		"ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551", 16)
+	return &DHKEM{id: 0x0010, curve: ecdh.P256(), order: n}
+}
+
+// ID returns the KEM identifier of RFC 9180, Section 7.1.
+func (d DHKEM) ID() uint16 {
+	return d.id
+}
+
+// EncapsulationSize returns the size of an encapsulation in bytes.
+func (d DHKEM) EncapsulationSize() int {
+	if d.order == nil {
+		return 32
+	}
+	return 65
+}
+
+// Generate creates a public/private key pair. A nil seed yields a fresh key pair, otherwise
+// the seed is used as input keying material of the RFC 9180 DeriveKeyPair function.
+func (d DHKEM) Generate(seed []byte) (pk, sk []byte, err error) {
+	if seed == nil {
+		seed = make([]byte, sha256.Size)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, nil, errors.Wrap(err, "unable to poll random source")
+		}
+	}
+
+	private, err := d.derive(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return private.PublicKey().Bytes(), private.Bytes(), nil
+}
+
+// Encapsulate generates and encapsulates a fresh symmetric key under a public key.
+func (d DHKEM) Encapsulate(pk []byte) (k, c []byte, err error) {
+	ikm := make([]byte, sha256.Size)
+	if _, err := rand.Read(ikm); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to poll random source")
+	}
+	return d.encapsulate(pk, ikm)
+}
+
+// Decapsulate decapsulates a symmetric key from a ciphertext with a private key.
+func (d DHKEM) Decapsulate(sk, ct []byte) ([]byte, error) {
+	private, err := d.curve.NewPrivateKey(sk)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode dhkem private key")
+	}
+	public, err := d.curve.NewPublicKey(ct)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode dhkem ciphertext")
+	}
+
+	dh, err := private.ECDH(public)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compute shared secret")
+	}
+	return d.extractAndExpand(dh, primitives.Concat(ct, private.PublicKey().Bytes()))
+}
+
+// encapsulate implements the encapsulation with an ephemeral key pair that is derived from
+// given input keying material.
+func (d DHKEM) encapsulate(pk, ikm []byte) (k, c []byte, err error) {
+	public, err := d.curve.NewPublicKey(pk)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to decode dhkem public key")
+	}
+	ephemeral, err := d.derive(ikm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dh, err := ephemeral.ECDH(public)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to compute shared secret")
+	}
+
+	c = ephemeral.PublicKey().Bytes()
+	k, err = d.extractAndExpand(dh, primitives.Concat(c, pk))
+	if err != nil {
+		return nil, nil, err
+	}
+	return k, c, nil
+}
+
+// derive implements the DeriveKeyPair function of RFC 9180, Section 7.1.3.
+func (d DHKEM) derive(ikm []byte) (*ecdh.PrivateKey, error) {
+	prk, err := d.labeledExtract(nil, "dkp_prk", ikm)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.order == nil {
+		sk, err := d.labeledExpand(prk, "sk", nil, 32)
+		if err != nil {
+			return nil, err
+		}
+		return d.curve.NewPrivateKey(sk)
+	}
+
+	for counter := 0; counter < 256; counter++ {
+		sk, err := d.labeledExpand(prk, "candidate", []byte{byte(counter)}, 32)
+		if err != nil {
+			return nil, err
+		}
+		if s := new(big.Int).SetBytes(sk); s.Sign() != 0 && s.Cmp(d.order) < 0 {
+			return d.curve.NewPrivateKey(sk)
+		}
+	}
+	return nil, errors.New("unable to derive dhkem key pair")
+}
+
+// extractAndExpand derives the shared secret from a Diffie-Hellman value and the context.
+func (d DHKEM) extractAndExpand(dh, context []byte) ([]byte, error) {
+	prk, err := d.labeledExtract(nil, "eae_prk", dh)
+	if err != nil {
+		return nil, err
+	}
+	return d.labeledExpand(prk, "shared_secret", context, sha256.Size)
+}
+
+// suite returns the KEM suite identifier.
+func (d DHKEM) suite() []byte {
+	var id [2]byte
+	binary.BigEndian.PutUint16(id[:], d.id)
+	return primitives.Concat([]byte("KEM"), id[:])
+}
+
+func (d DHKEM) labeledExtract(salt []byte, label string, ikm []byte) ([]byte, error) {
+	return labeledExtract(sha256.New, d.suite(), salt, label, ikm)
+}
+
+func (d DHKEM) labeledExpand(prk []byte, label string, info []byte, n int) ([]byte, error) {
+	return labeledExpand(sha256.New, d.suite(), prk, label, info, n)
+}
+
+// labeledExtract implements the LabeledExtract function of RFC 9180, Section 4.
+func labeledExtract(h func() hash.Hash, suite, salt []byte, label string, ikm []byte) ([]byte, error) {
+	ikm = primitives.Concat([]byte(hpkeVersion), suite, []byte(label), ikm)
+	return hkdf.Extract(h, ikm, salt), nil
+}
+
+// labeledExpand implements the LabeledExpand function of RFC 9180, Section 4.
+func labeledExpand(h func() hash.Hash, suite, prk []byte, label string, info []byte, n int) ([]byte, error) {
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(n))
+
+	info = primitives.Concat(length[:], []byte(hpkeVersion), suite, []byte(label), info)
+	k := make([]byte, n)
+	if _, err := io.ReadFull(hkdf.Expand(h, prk, info), k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
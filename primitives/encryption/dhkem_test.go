@@ -0,0 +1,94 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDHKEM(t *testing.T) {
+	require := require.New(t)
+
+	for _, kem := range []Encapsulation{NewDHKEMX25519(), NewDHKEMP256()} {
+		pk, sk, err := kem.Generate(nil)
+		require.Nil(err)
+
+		ka, c, err := kem.Encapsulate(pk)
+		require.Nil(err)
+
+		kb, err := kem.Decapsulate(sk, c)
+		require.Nil(err)
+		require.True(bytes.Equal(ka, kb))
+
+		seed := []byte("seed")
+		pk1, sk1, err := kem.Generate(seed)
+		require.Nil(err)
+		pk2, sk2, err := kem.Generate(seed)
+		require.Nil(err)
+		require.True(bytes.Equal(pk1, pk2))
+		require.True(bytes.Equal(sk1, sk2))
+
+		_, err = kem.Decapsulate(sk, c[:len(c)-1])
+		require.NotNil(err)
+	}
+}
+
+// TestDHKEM_RFC9180 checks the key derivation and encapsulation of the base mode test
+// vectors of RFC 9180, Appendices A.1.1 and A.3.1.
+func TestDHKEM_RFC9180(t *testing.T) {
+	require := require.New(t)
+
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.Nil(err)
+		return b
+	}
+
+	vectors := []struct {
+		kem                         *DHKEM
+		ikmE, ikmR, skRm, pkRm, enc string
+		sharedSecret                string
+	}{
+		{
+			kem:          NewDHKEMX25519(),
+			ikmE:         "7268600d403fce431561aef583ee1613527cff655c1343f29812e66706df3234",
+			ikmR:         "6db9df30aa07dd42ee5e8181afdb977e538f5e1fec8a06223f33f7013e525037",
+			skRm:         "4612c550263fc8ad58375df3f557aac531d26850903e55a9f23f21d8534e8ac8",
+			pkRm:         "3948cfe0ad1ddb695d780e59077195da6c56506b027329794ab02bca80815c4d",
+			enc:          "37fda3567bdbd628e88668c3c8d7e97d1d1253b6d4ea6d44c150f741f1bf4431",
+			sharedSecret: "fe0e18c9f024ce43799ae393c7e8fe8fce9d218875e8227b0187c04e7d2ea1fc",
+		},
+		{
+			kem:  NewDHKEMP256(),
+			ikmE: "4270e54ffd08d79d5928020af4686d8f6b7d35dbe470265f1f5aa22816ce860e",
+			ikmR: "668b37171f1072f3cf12ea8a236a45df23fc13b82af3609ad1e354f6ef817550",
+			skRm: "f3ce7fdae57e1a310d87f1ebbde6f328be0a99cdbcadf4d6589cf29de4b8ffd2",
+			pkRm: "04fe8c19ce0905191ebc298a9245792531f26f0cece2460639e8bc39cb7f706a8" +
+				"26a779b4cf969b8a0e539c7f62fb3d30ad6aa8f80e30f1d128aafd68a2ce72ea0",
+			enc: "04a92719c6195d5085104f469a8b9814d5838ff72b60501e2c4466e5e67b325ac9" +
+				"8536d7b61a1af4b78e5b7f951c0900be863c403ce65c9bfcb9382657222d18c4",
+			sharedSecret: "c0d26aeab536609a572b07695d933b589dcf363ff9d93c93adea537aeabb8cb8",
+		},
+	}
+
+	for _, v := range vectors {
+		pk, sk, err := v.kem.Generate(decode(v.ikmR))
+		require.Nil(err)
+		require.Equal(decode(v.skRm), sk)
+		require.Equal(decode(v.pkRm), pk)
+
+		k, c, err := v.kem.encapsulate(pk, decode(v.ikmE))
+		require.Nil(err)
+		require.Equal(decode(v.enc), c)
+		require.Equal(decode(v.sharedSecret), k)
+
+		k, err = v.kem.Decapsulate(sk, c)
+		require.Nil(err)
+		require.Equal(decode(v.sharedSecret), k)
+	}
+}
@@ -0,0 +1,122 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// x25519KeySize is the size of X25519 public and private keys.
+const x25519KeySize = 32
+
+// X25519ECIES implements ECIES over Curve25519 with a compact ciphertext encoding. A
+// ciphertext is the fixed-size ephemeral public key followed by an AES-GCM ciphertext.
+// Since every ephemeral key yields a fresh AES-GCM key, the nonce is fixed and omitted.
+type X25519ECIES struct{}
+
+// NewX25519ECIES creates a fresh X25519 ECIES instance.
+func NewX25519ECIES() *X25519ECIES {
+	return &X25519ECIES{}
+}
+
+// Generate creates a X25519 ECIES public/private key pair. A nil seed yields a fresh key
+// pair sampled from crypto/rand, otherwise the seed is expanded deterministically.
+func (x X25519ECIES) Generate(seed []byte) (pk, sk []byte, err error) {
+	k := make([]byte, x25519KeySize)
+	if _, err := io.ReadFull(primitives.NewDRBG(seed, "ecies-x25519"), k); err != nil {
+		return nil, nil, err
+	}
+
+	private, err := ecdh.X25519().NewPrivateKey(k)
+	if err != nil {
+		return nil, nil, err
+	}
+	return private.PublicKey().Bytes(), private.Bytes(), nil
+}
+
+// Encrypt enciphers a message and associated data with a given public key.
+func (x X25519ECIES) Encrypt(pk, msg, ad []byte) ([]byte, error) {
+	public, err := ecdh.X25519().NewPublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	dh, err := ephemeral.ECDH(public)
+	if err != nil {
+		return nil, err
+	}
+
+	epk := ephemeral.PublicKey().Bytes()
+	gcm, err := x.gcm(dh, epk, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+	return gcm.Seal(epk, nonce[:], msg, ad), nil
+}
+
+// Decrypt deciphers a ciphertext and associated data with a given private key.
+func (x X25519ECIES) Decrypt(sk, ct, ad []byte) ([]byte, error) {
+	if len(ct) < x25519KeySize+16 {
+		return nil, fmt.Errorf("invalid ciphertext size: %v", len(ct))
+	}
+
+	private, err := ecdh.X25519().NewPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	epk := ct[:x25519KeySize]
+	ephemeral, err := ecdh.X25519().NewPublicKey(epk)
+	if err != nil {
+		return nil, err
+	}
+
+	dh, err := private.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := x.gcm(dh, epk, private.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+	msg, err := gcm.Open(nil, nonce[:], ct[x25519KeySize:], ad)
+	if err != nil {
+		return nil, errors.New("failed to decrypt ciphertext")
+	}
+	return msg, nil
+}
+
+// gcm derives the AES-GCM instance from the shared secret and both public keys.
+func (x X25519ECIES) gcm(dh, epk, pk []byte) (cipher.AEAD, error) {
+	k := make([]byte, aesKeySize)
+	info := primitives.Concat([]byte("ecies-x25519"), epk, pk)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, dh, nil, info), k); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,50 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestX25519ECIES(t *testing.T) {
+	require := require.New(t)
+
+	ecies := NewX25519ECIES()
+
+	pk, sk, err := ecies.Generate(nil)
+	require.Nil(err)
+	require.Equal(32, len(pk))
+
+	msg, ad := []byte("ecies"), []byte("ad")
+
+	ct, err := ecies.Encrypt(pk, msg, ad)
+	require.Nil(err)
+	require.Equal(32+len(msg)+16, len(ct))
+
+	pt, err := ecies.Decrypt(sk, ct, ad)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	_, err = ecies.Decrypt(sk, ct, nil)
+	require.NotNil(err)
+
+	ct[0] ^= 1
+	_, err = ecies.Decrypt(sk, ct, ad)
+	require.NotNil(err)
+
+	_, err = ecies.Decrypt(sk, ct[:40], ad)
+	require.NotNil(err)
+
+	// The compact encoding is smaller than the one of the P-256 variant.
+	p256 := NewECIES(elliptic.P256())
+	pk, _, err = p256.Generate(nil)
+	require.Nil(err)
+	c, err := p256.Encrypt(pk, msg, ad)
+	require.Nil(err)
+	require.True(len(ct) < len(c))
+}
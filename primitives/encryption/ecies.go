@@ -4,12 +4,10 @@
 package encryption
 
 import (
-	"bytes"
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
-	"crypto/sha512"
 	"errors"
 	"io"
 	"math/big"
@@ -21,11 +19,17 @@ import (
 	"github.com/qantik/ratcheted/primitives"
 )
 
+// eciesVersion tags ciphertexts that use AES-GCM as the DEM. Legacy AES-CBC ciphertexts
+// start with the varint encoded length of a curve coordinate which never has the high bit
+// set, hence the two formats cannot be confused.
+const eciesVersion byte = 0x82
+
 // ECIES implements the Elliptic Curve Integrated Encryption Scheme on a given curve using
-// HS256 and AES-GCM as the authentication and encryption primitives and HDKF for key derivation.
+// AES-GCM as the authenticated encryption primitive and HDKF for key derivation.
 type ECIES struct {
 	curve elliptic.Curve
 	aes   *AES
+	gcm   *GCM
 }
 
 // eciesPublicKey wraps the x and y coordinate of a group point.
@@ -38,7 +42,8 @@ type eciesPrivateKey struct {
 	K []byte
 }
 
-// eciesCiphertext bundles the ciphertext material.
+// eciesCiphertext bundles the ciphertext material. D only holds the HMAC tag of legacy
+// ciphertexts and is empty otherwise.
 type eciesCiphertext struct {
 	Rx, Ry []byte
 	C, D   []byte
@@ -46,12 +51,13 @@ type eciesCiphertext struct {
 
 // NewECIES creates a fresh ECIES object instance.
 func NewECIES(curve elliptic.Curve) *ECIES {
-	return &ECIES{curve: curve, aes: NewAES()}
+	return &ECIES{curve: curve, aes: NewAES(), gcm: NewGCM()}
 }
 
-// Generate creates a ECIES public/private key pair.
+// Generate creates a ECIES public/private key pair. A nil seed yields a fresh key pair
+// sampled from crypto/rand, otherwise the seed is expanded deterministically.
 func (e ECIES) Generate(seed []byte) (pk, sk []byte, err error) {
-	k, err := e.randomFieldScalar(primitives.Digest(sha512.New(), seed))
+	k, err := e.randomFieldScalar(primitives.NewDRBG(seed, "ecies"))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -71,7 +77,8 @@ func (e ECIES) Generate(seed []byte) (pk, sk []byte, err error) {
 	return
 }
 
-// Encrypt enciphers a message with a given public key.
+// Encrypt enciphers a message with a given public key. The associated data and the
+// ephemeral point are authenticated by AES-GCM alongside the message.
 func (e ECIES) Encrypt(pk, msg, ad []byte) ([]byte, error) {
 	var public eciesPublicKey
 	if err := binary.Unmarshal(pk, &public); err != nil {
@@ -80,7 +87,7 @@ func (e ECIES) Encrypt(pk, msg, ad []byte) ([]byte, error) {
 
 	kx, ky := new(big.Int).SetBytes(public.Kx), new(big.Int).SetBytes(public.Ky)
 
-	r, err := e.randomFieldScalar(nil)
+	r, err := e.randomFieldScalar(rand.Reader)
 	if err != nil {
 		return nil, err
 	}
@@ -88,80 +95,104 @@ func (e ECIES) Encrypt(pk, msg, ad []byte) ([]byte, error) {
 	Rx, Ry := e.curve.ScalarBaseMult(r.Bytes())
 	Px, _ := e.curve.ScalarMult(kx, ky, r.Bytes())
 
-	hkdf := hkdf.New(sha256.New, Px.Bytes(), nil, nil)
+	ct := eciesCiphertext{Rx: Rx.Bytes(), Ry: Ry.Bytes()}
 
-	ke := make([]byte, 16)
-	if _, err := io.ReadFull(hkdf, ke); err != nil {
+	binding, err := e.binding(&ct, ad)
+	if err != nil {
 		return nil, err
 	}
-	km := make([]byte, 16)
-	if _, err := io.ReadFull(hkdf, km); err != nil {
+	k, err := e.derive(Px, binding, 16)
+	if err != nil {
 		return nil, err
 	}
 
-	c, err := e.aes.Encrypt(ke, msg)
+	ct.C, err = e.gcm.Encrypt(k, msg, binding)
 	if err != nil {
 		return nil, err
 	}
 
-	d := primitives.Digest(hmac.New(sha256.New, km), c)
-	ct := eciesCiphertext{Rx: Rx.Bytes(), Ry: Ry.Bytes(), C: c, D: d}
-
-	return binary.Marshal(&ct)
+	c, err := binary.Marshal(&ct)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{eciesVersion}, c...), nil
 }
 
-// Decrypt deciphers a ciphertex with a given private key.
+// Decrypt deciphers a ciphertex with a given private key. Legacy ciphertexts that have
+// been produced with AES-CBC and HMAC are still accepted, note however that they do not
+// authenticate the associated data.
 func (e ECIES) Decrypt(sk, ct, ad []byte) ([]byte, error) {
 	var private eciesPrivateKey
 	if err := binary.Unmarshal(sk, &private); err != nil {
 		return nil, err
 	}
+
+	legacy := len(ct) == 0 || ct[0] != eciesVersion
+	if !legacy {
+		ct = ct[1:]
+	}
+
 	var ciphertext eciesCiphertext
 	if err := binary.Unmarshal(ct, &ciphertext); err != nil {
 		return nil, err
 	}
 
 	rx, ry := new(big.Int).SetBytes(ciphertext.Rx), new(big.Int).SetBytes(ciphertext.Ry)
+	if !e.curve.IsOnCurve(rx, ry) {
+		return nil, errors.New("invalid ephemeral point")
+	}
 
 	Px, _ := e.curve.ScalarMult(rx, ry, private.K)
 
-	hkdf := hkdf.New(sha256.New, Px.Bytes(), nil, nil)
+	if legacy {
+		return e.decryptLegacy(Px, &ciphertext)
+	}
 
-	ke := make([]byte, 16)
-	n, err := io.ReadFull(hkdf, ke)
-	if n != len(ke) || err != nil {
+	binding, err := e.binding(&ciphertext, ad)
+	if err != nil {
 		return nil, err
 	}
-	km := make([]byte, 16)
-	n, err = io.ReadFull(hkdf, km)
-	if n != len(km) || err != nil {
+	k, err := e.derive(Px, binding, 16)
+	if err != nil {
 		return nil, err
 	}
+	return e.gcm.Decrypt(k, ciphertext.C, binding)
+}
+
+// decryptLegacy deciphers a ciphertext of the original AES-CBC and HMAC construction.
+func (e ECIES) decryptLegacy(Px *big.Int, ciphertext *eciesCiphertext) ([]byte, error) {
+	k, err := e.derive(Px, nil, 32)
+	if err != nil {
+		return nil, err
+	}
+	ke, km := k[:16], k[16:]
 
 	tau := primitives.Digest(hmac.New(sha256.New, km), ciphertext.C)
-	if !bytes.Equal(tau, ciphertext.D) {
+	if !hmac.Equal(tau, ciphertext.D) {
 		return nil, errors.New("failed to verify mac")
 	}
+	return e.aes.Decrypt(ke, ciphertext.C)
+}
 
-	msg, err := e.aes.Decrypt(ke, ciphertext.C)
-	if err != nil {
+// binding encodes the ephemeral point together with the associated data.
+func (e ECIES) binding(ct *eciesCiphertext, ad []byte) ([]byte, error) {
+	return binary.Marshal(&struct{ Rx, Ry, AD []byte }{ct.Rx, ct.Ry, ad})
+}
+
+// derive extracts a key of a given size from the shared point with HKDF.
+func (e ECIES) derive(Px *big.Int, info []byte, size int) ([]byte, error) {
+	k := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, Px.Bytes(), nil, info), k); err != nil {
 		return nil, err
 	}
-	return msg, nil
+	return k, nil
 }
 
-// randomFieldElement returns a random group scalar.
-func (e ECIES) randomFieldScalar(seed []byte) (*big.Int, error) {
+// randomFieldScalar returns a group scalar sampled from a given source of randomness.
+func (e ECIES) randomFieldScalar(reader io.Reader) (*big.Int, error) {
 	params := e.curve.Params()
 	b := make([]byte, params.BitSize/8+8)
 
-	var reader io.Reader
-	if seed == nil {
-		reader = rand.Reader
-	} else {
-		reader = bytes.NewReader(seed)
-	}
-
 	if _, err := io.ReadFull(reader, b); err != nil {
 		return nil, err
 	}
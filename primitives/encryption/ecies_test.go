@@ -6,9 +6,16 @@ package encryption
 import (
 	"bytes"
 	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
 	"testing"
 
+	"github.com/alecthomas/binary"
 	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives"
 )
 
 func TestECIES(t *testing.T) {
@@ -28,6 +35,107 @@ func TestECIES(t *testing.T) {
 	require.True(bytes.Equal(msg, pt))
 }
 
+func TestECIES_AssociatedData(t *testing.T) {
+	require := require.New(t)
+
+	ecies := NewECIES(elliptic.P256())
+
+	pk, sk, err := ecies.Generate(nil)
+	require.Nil(err)
+
+	msg, ad := []byte("ecies"), []byte("ad")
+
+	ct, err := ecies.Encrypt(pk, msg, ad)
+	require.Nil(err)
+
+	pt, err := ecies.Decrypt(sk, ct, ad)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	_, err = ecies.Decrypt(sk, ct, []byte("da"))
+	require.NotNil(err)
+	_, err = ecies.Decrypt(sk, ct, nil)
+	require.NotNil(err)
+
+	// Swap the ephemeral point for one of a different ciphertext.
+	other, err := ecies.Encrypt(pk, msg, ad)
+	require.Nil(err)
+
+	var c1, c2 eciesCiphertext
+	require.Nil(binary.Unmarshal(ct[1:], &c1))
+	require.Nil(binary.Unmarshal(other[1:], &c2))
+	c1.Rx, c1.Ry = c2.Rx, c2.Ry
+	forged, err := binary.Marshal(&c1)
+	require.Nil(err)
+
+	_, err = ecies.Decrypt(sk, append([]byte{eciesVersion}, forged...), ad)
+	require.NotNil(err)
+}
+
+func TestECIES_Legacy(t *testing.T) {
+	require := require.New(t)
+
+	ecies := NewECIES(elliptic.P256())
+
+	pk, sk, err := ecies.Generate(nil)
+	require.Nil(err)
+
+	msg := []byte("ecies")
+
+	// Reproduce the original AES-CBC and HMAC ciphertext format.
+	var public eciesPublicKey
+	require.Nil(binary.Unmarshal(pk, &public))
+	kx, ky := new(big.Int).SetBytes(public.Kx), new(big.Int).SetBytes(public.Ky)
+
+	r, err := ecies.randomFieldScalar(rand.Reader)
+	require.Nil(err)
+	Rx, Ry := ecies.curve.ScalarBaseMult(r.Bytes())
+	Px, _ := ecies.curve.ScalarMult(kx, ky, r.Bytes())
+
+	k, err := ecies.derive(Px, nil, 32)
+	require.Nil(err)
+	c, err := ecies.aes.Encrypt(k[:16], msg)
+	require.Nil(err)
+	d := primitives.Digest(hmac.New(sha256.New, k[16:]), c)
+
+	ct, err := binary.Marshal(&eciesCiphertext{Rx: Rx.Bytes(), Ry: Ry.Bytes(), C: c, D: d})
+	require.Nil(err)
+
+	pt, err := ecies.Decrypt(sk, ct, nil)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	ct[len(ct)-1] ^= 1
+	_, err = ecies.Decrypt(sk, ct, nil)
+	require.NotNil(err)
+}
+
+func TestECIES_Generate(t *testing.T) {
+	require := require.New(t)
+
+	ecies := NewECIES(elliptic.P256())
+
+	pk1, sk1, err := ecies.Generate(nil)
+	require.Nil(err)
+	pk2, sk2, err := ecies.Generate(nil)
+	require.Nil(err)
+	require.False(bytes.Equal(pk1, pk2))
+	require.False(bytes.Equal(sk1, sk2))
+
+	seed := []byte("ecies seed")
+
+	pk1, sk1, err = ecies.Generate(seed)
+	require.Nil(err)
+	pk2, sk2, err = ecies.Generate(seed)
+	require.Nil(err)
+	require.True(bytes.Equal(pk1, pk2))
+	require.True(bytes.Equal(sk1, sk2))
+
+	pk2, _, err = ecies.Generate([]byte("another seed"))
+	require.Nil(err)
+	require.False(bytes.Equal(pk1, pk2))
+}
+
 //func TestEciesKEM(t *testing.T) {
 //	require := require.New(t)
 //
@@ -0,0 +1,232 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// sivTagSize is the size of an AES-GCM-SIV authentication tag.
+const sivTagSize = 16
+
+// GCMSIV implements the nonce-misuse-resistant AES-GCM-SIV AEAD scheme (RFC 8452).
+//
+// By default a random nonce is chosen for each encryption and prepended to the ciphertext.
+// In one-time mode a fixed nonce is used instead which is not transmitted. This is only
+// sound if each key is used to encrypt a single message, as it is the case for the message
+// keys of the ratcheting protocols. Even if a key is reused, GCM-SIV only leaks whether
+// two identical messages have been encrypted.
+type GCMSIV struct {
+	oneTime bool
+}
+
+// NewGCMSIV returns a fresh AES-GCM-SIV instance with random nonces.
+func NewGCMSIV() *GCMSIV {
+	return &GCMSIV{oneTime: false}
+}
+
+// NewOneTimeGCMSIV returns a fresh AES-GCM-SIV instance with a fixed nonce.
+func NewOneTimeGCMSIV() *GCMSIV {
+	return &GCMSIV{oneTime: true}
+}
+
+// Encrypt applies the AES-GCM-SIV encryption/authentication routine to a given message
+// and associated data.
+func (g GCMSIV) Encrypt(key, msg, ad []byte) ([]byte, error) {
+	var nonce [nonceSize]byte
+	if !g.oneTime {
+		if _, err := rand.Read(nonce[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	ke, ka, err := sivKeys(key, nonce[:])
+	if err != nil {
+		return nil, err
+	}
+
+	tag := sivTag(ke, ka, nonce[:], msg, ad)
+
+	ct := make([]byte, len(msg), len(msg)+sivTagSize)
+	sivCTR(ke, tag, ct, msg)
+	ct = append(ct, tag...)
+
+	if g.oneTime {
+		return ct, nil
+	}
+	return append(nonce[:], ct...), nil
+}
+
+// Decrypt applies the AES-GCM-SIV decryption routine to a given ciphertext and
+// associated data.
+func (g GCMSIV) Decrypt(key, ct, ad []byte) ([]byte, error) {
+	var nonce [nonceSize]byte
+	if !g.oneTime {
+		if len(ct) < nonceSize {
+			return nil, fmt.Errorf("invalid ciphertext size: %v", len(ct))
+		}
+		copy(nonce[:], ct[:nonceSize])
+		ct = ct[nonceSize:]
+	}
+	if len(ct) < sivTagSize {
+		return nil, fmt.Errorf("invalid ciphertext size: %v", len(ct))
+	}
+
+	ke, ka, err := sivKeys(key, nonce[:])
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(ct) - sivTagSize
+	tag := ct[n:]
+
+	msg := make([]byte, n)
+	sivCTR(ke, tag, msg, ct[:n])
+
+	if subtle.ConstantTimeCompare(tag, sivTag(ke, ka, nonce[:], msg, ad)) != 1 {
+		return nil, errors.New("failed to verify tag")
+	}
+	return msg, nil
+}
+
+// sivKeys derives the per-nonce message encryption and authentication keys from the
+// key-generating key.
+func sivKeys(key, nonce []byte) (ke cipher.Block, ka []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	derive := func(n int) []byte {
+		var in, out [aes.BlockSize]byte
+		copy(in[4:], nonce)
+
+		k := make([]byte, 0, n*8)
+		for i := 0; i < n; i++ {
+			binary.LittleEndian.PutUint32(in[:4], uint32(i))
+			block.Encrypt(out[:], in[:])
+			k = append(k, out[:8]...)
+		}
+		return k
+	}
+
+	k := derive(2 + len(key)/8)
+	ka, ek := k[:16], k[16:]
+
+	ke, err = aes.NewCipher(ek)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ke, ka, nil
+}
+
+// sivTag computes the authentication tag of a message and associated data.
+func sivTag(ke cipher.Block, ka, nonce, msg, ad []byte) []byte {
+	var lengths [aes.BlockSize]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(ad))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(msg))*8)
+
+	s := polyval(ka, pad16(ad), pad16(msg), lengths[:])
+	for i := range nonce {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+
+	tag := make([]byte, sivTagSize)
+	ke.Encrypt(tag, s)
+	return tag
+}
+
+// sivCTR applies the AES-GCM-SIV counter mode with a 32-bit little-endian counter that is
+// initialized from the tag.
+func sivCTR(ke cipher.Block, tag, dst, src []byte) {
+	var counter, stream [aes.BlockSize]byte
+	copy(counter[:], tag)
+	counter[15] |= 0x80
+
+	for i := 0; i < len(src); i += aes.BlockSize {
+		ke.Encrypt(stream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+
+		for j := 0; j < aes.BlockSize && i+j < len(src); j++ {
+			dst[i+j] = src[i+j] ^ stream[j]
+		}
+	}
+}
+
+// pad16 pads a byte slice with zeroes to a multiple of 16 bytes.
+func pad16(b []byte) []byte {
+	if len(b)%16 == 0 {
+		return b
+	}
+	return append(append([]byte{}, b...), make([]byte, 16-len(b)%16)...)
+}
+
+// polyval computes the POLYVAL universal hash over the concatenation of the given data.
+// It is evaluated via its relation to GHASH as specified in RFC 8452, Appendix A.
+func polyval(h []byte, data ...[]byte) []byte {
+	hh, hl := gfMulX(gfLoad(reverse(h)))
+
+	var sh, sl uint64
+	for _, d := range data {
+		for i := 0; i < len(d); i += 16 {
+			xh, xl := gfLoad(reverse(d[i : i+16]))
+			sh, sl = gfMul(sh^xh, sl^xl, hh, hl)
+		}
+	}
+
+	s := make([]byte, 16)
+	binary.BigEndian.PutUint64(s[:8], sh)
+	binary.BigEndian.PutUint64(s[8:], sl)
+	return reverse(s)
+}
+
+// gfLoad interprets a 16-byte block as a GHASH field element.
+func gfLoad(b []byte) (hi, lo uint64) {
+	return binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+}
+
+// gfMulX multiplies a GHASH field element with x. The reduction is masked instead of
+// branched on so that the running time does not depend on secret data.
+func gfMulX(hi, lo uint64) (uint64, uint64) {
+	carry := lo & 1
+	lo = lo>>1 | hi<<63
+	hi >>= 1
+	hi ^= 0xe1 << 56 & -carry
+	return hi, lo
+}
+
+// gfMul multiplies two GHASH field elements (NIST SP 800-38D, Algorithm 1) in constant
+// time.
+func gfMul(xh, xl, yh, yl uint64) (zh, zl uint64) {
+	vh, vl := yh, yl
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = xh >> uint(63-i) & 1
+		} else {
+			bit = xl >> uint(127-i) & 1
+		}
+		mask := -bit
+		zh ^= vh & mask
+		zl ^= vl & mask
+		vh, vl = gfMulX(vh, vl)
+	}
+	return
+}
+
+// reverse returns a copy of a byte slice in reverse order.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,121 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCMSIV(t *testing.T) {
+	require := require.New(t)
+
+	for _, siv := range []*GCMSIV{NewGCMSIV(), NewOneTimeGCMSIV()} {
+		for _, size := range []int{16, 32} {
+			key := make([]byte, size)
+
+			msg, ad := []byte("aes-gcm-siv"), []byte("associated-data")
+
+			ct, err := siv.Encrypt(key, msg, ad)
+			require.Nil(err)
+
+			pt, err := siv.Decrypt(key, ct, ad)
+			require.Nil(err)
+			require.True(bytes.Equal(msg, pt))
+
+			_, err = siv.Decrypt(key, ct, nil)
+			require.NotNil(err)
+
+			ct[0] ^= 1
+			_, err = siv.Decrypt(key, ct, ad)
+			require.NotNil(err)
+
+			_, err = siv.Decrypt(key, ct[:10], ad)
+			require.NotNil(err)
+		}
+	}
+
+	// The one-time mode saves the transmission of the nonce.
+	key := make([]byte, 16)
+	c1, err := NewGCMSIV().Encrypt(key, []byte("msg"), nil)
+	require.Nil(err)
+	c2, err := NewOneTimeGCMSIV().Encrypt(key, []byte("msg"), nil)
+	require.Nil(err)
+	require.Equal(len(c1)-nonceSize, len(c2))
+}
+
+// TestGCMSIV_RFC8452 checks test vectors of RFC 8452, Appendices A and C.
+func TestGCMSIV_RFC8452(t *testing.T) {
+	require := require.New(t)
+
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.Nil(err)
+		return b
+	}
+
+	h := decode("25629347589242761d31f826ba4b757b")
+	x1 := decode("4f4f95668c83dfb6401762bb2d01a262")
+	x2 := decode("d1a24ddd2721d006bbe45f20d3c9f362")
+	require.Equal(decode("f7a3b47b846119fae5b7866cf5e5b77e"), polyval(h, x1, x2))
+
+	vectors := []struct {
+		key, nonce, msg, ad, ct string
+	}{
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"", "", "dc20e2d83f25705bb49e439eca56de25",
+		},
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"0100000000000000", "", "b5d839330ac7b786578782fff6013b815b287c22493a364c",
+		},
+		{
+			"0100000000000000000000000000000000000000000000000000000000000000",
+			"030000000000000000000000", "", "", "07f5f4169bbf55a8400cd47ea6fd400f",
+		},
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"0200000000000000", "01", "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508",
+		},
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"010000000000000000000000000000000200000000000000000000000000000003000000000000000000000000000000",
+			"", "3fd24ce1f5a67b75bf2351f181a475c7b800a5b4d3dcf70106b1eea82fa1d64df42bf7226122fa92e17a40eeaac1201b5e6e311dbf395d35b0fe39c2714388f8",
+		},
+		{
+			"bde3b2f204d1e9f8b06bc47f9745b3d1", "ae06556fb6aa7890bebc18fe",
+			"6b3db4da3d57aa94842b9803a96e07fb6de7", "1860f762ebfbd08284e421702de0de18baa9c9596291b08466f37de21c7f",
+			"6298b296e24e8cc35dce0bed484b7f30d5803e377094f04709f64d7b985310a4db84",
+		},
+		{
+			"3c535de192eaed3822a2fbbe2ca9dfc88255e14a661b8aa82cc54236093bbc23", "688089e55540db1872504e1c",
+			"ced532ce4159b035277d4dfbb7db62968b13cd4eec", "734320ccc9d9bbbb19cb81b2af4ecbc3e72834321f7aa0f70b7282b4f33df23f167541",
+			"626660c26ea6612fb17ad91e8e767639edd6c9faee9d6c7029675b89eaf4ba1ded1a286594",
+		},
+		// Counter wrap (Appendix C.3).
+		{
+			"0000000000000000000000000000000000000000000000000000000000000000", "000000000000000000000000",
+			"000000000000000000000000000000004db923dc793ee6497c76dcc03a98e108", "",
+			"f3f80f2cf0cb2dd9c5984fcda908456cc537703b5ba70324a6793a7bf218d3eaffffffff000000000000000000000000",
+		},
+		{
+			"0000000000000000000000000000000000000000000000000000000000000000", "000000000000000000000000",
+			"eb3640277c7ffd1303c7a542d02d3e4c0000000000000000", "",
+			"18ce4f0b8cb4d0cac65fea8f79257b20888e53e72299e56dffffffff000000000000000000000000",
+		},
+	}
+
+	siv := NewGCMSIV()
+	for _, v := range vectors {
+		ct := append(decode(v.nonce), decode(v.ct)...)
+
+		pt, err := siv.Decrypt(decode(v.key), ct, decode(v.ad))
+		require.Nil(err)
+		require.Equal(decode(v.msg), pt)
+	}
+}
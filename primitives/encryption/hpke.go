@@ -0,0 +1,219 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// HPKEKEM is a key-encapsulation mechanism that can be used in HPKE.
+type HPKEKEM interface {
+	Encapsulation
+	// ID returns the KEM identifier of RFC 9180, Section 7.1.
+	ID() uint16
+	// EncapsulationSize returns the size of an encapsulation in bytes.
+	EncapsulationSize() int
+}
+
+// HPKEKDF is a HKDF instance that can be used in HPKE.
+type HPKEKDF struct {
+	id   uint16
+	hash func() hash.Hash
+}
+
+// HPKEAEAD is an AEAD scheme that can be used in HPKE.
+type HPKEAEAD struct {
+	id      uint16
+	keySize int
+	new     func(key []byte) (cipher.AEAD, error)
+}
+
+// HKDFSHA256 returns the HKDF-SHA256 key derivation function.
+func HKDFSHA256() HPKEKDF { return HPKEKDF{id: 0x0001, hash: sha256.New} }
+
+// HKDFSHA384 returns the HKDF-SHA384 key derivation function.
+func HKDFSHA384() HPKEKDF { return HPKEKDF{id: 0x0002, hash: sha512.New384} }
+
+// HKDFSHA512 returns the HKDF-SHA512 key derivation function.
+func HKDFSHA512() HPKEKDF { return HPKEKDF{id: 0x0003, hash: sha512.New} }
+
+// HPKEAES128GCM returns the AES-128-GCM AEAD scheme.
+func HPKEAES128GCM() HPKEAEAD { return HPKEAEAD{id: 0x0001, keySize: 16, new: newGCM} }
+
+// HPKEAES256GCM returns the AES-256-GCM AEAD scheme.
+func HPKEAES256GCM() HPKEAEAD { return HPKEAEAD{id: 0x0002, keySize: 32, new: newGCM} }
+
+// HPKEChaCha20Poly1305 returns the ChaCha20-Poly1305 AEAD scheme.
+func HPKEChaCha20Poly1305() HPKEAEAD {
+	return HPKEAEAD{id: 0x0003, keySize: chacha20poly1305.KeySize, new: chacha20poly1305.New}
+}
+
+// HPKE implements the base mode of the Hybrid Public Key Encryption scheme (RFC 9180)
+// with an empty info string. A ciphertext is the concatenation of the encapsulation and
+// a single AEAD ciphertext in which the associated data is authenticated.
+type HPKE struct {
+	kem  HPKEKEM
+	kdf  HPKEKDF
+	aead HPKEAEAD
+}
+
+// hpkeContext is an encryption context that has been established by the key schedule.
+type hpkeContext struct {
+	aead     cipher.AEAD
+	nonce    []byte
+	seq      uint64
+	exporter []byte
+	hpke     *HPKE
+}
+
+// NewHPKE creates a fresh HPKE instance from a KEM, a KDF and an AEAD scheme.
+func NewHPKE(kem HPKEKEM, kdf HPKEKDF, aead HPKEAEAD) *HPKE {
+	return &HPKE{kem: kem, kdf: kdf, aead: aead}
+}
+
+// Generate creates a public/private key pair of the underlying KEM.
+func (h HPKE) Generate(seed []byte) (pk, sk []byte, err error) {
+	return h.kem.Generate(seed)
+}
+
+// Encrypt enciphers a message and associated data with the given public key.
+func (h HPKE) Encrypt(pk, msg, ad []byte) ([]byte, error) {
+	k, enc, err := h.kem.Encapsulate(pk)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encapsulate key")
+	}
+
+	ctx, err := h.context(k, nil)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := ctx.seal(ad, msg)
+	if err != nil {
+		return nil, err
+	}
+	return primitives.Concat(enc, ct), nil
+}
+
+// Decrypt deciphers a ciphertext and associated data with the given private key.
+func (h HPKE) Decrypt(sk, ct, ad []byte) ([]byte, error) {
+	n := h.kem.EncapsulationSize()
+	if len(ct) < n {
+		return nil, errors.Errorf("invalid ciphertext size: %v", len(ct))
+	}
+
+	k, err := h.kem.Decapsulate(sk, ct[:n])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decapsulate key")
+	}
+
+	ctx, err := h.context(k, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.open(ad, ct[n:])
+}
+
+// context implements the base mode key schedule of RFC 9180, Section 5.1.
+func (h *HPKE) context(shared, info []byte) (*hpkeContext, error) {
+	hashes := make([][]byte, 2)
+	for i, in := range []struct {
+		label string
+		ikm   []byte
+	}{{"psk_id_hash", nil}, {"info_hash", info}} {
+		var err error
+		if hashes[i], err = h.labeledExtract(nil, in.label, in.ikm); err != nil {
+			return nil, errors.Wrap(err, "unable to compute key schedule")
+		}
+	}
+	context := primitives.Concat([]byte{0x00}, hashes[0], hashes[1])
+
+	secret, err := h.labeledExtract(shared, "secret", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compute key schedule")
+	}
+
+	key, err := h.labeledExpand(secret, "key", context, h.aead.keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compute key schedule")
+	}
+	nonce, err := h.labeledExpand(secret, "base_nonce", context, nonceSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compute key schedule")
+	}
+	exporter, err := h.labeledExpand(secret, "exp", context, h.kdf.hash().Size())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compute key schedule")
+	}
+
+	aead, err := h.aead.new(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create aead")
+	}
+	return &hpkeContext{aead: aead, nonce: nonce, exporter: exporter, hpke: h}, nil
+}
+
+// suite returns the HPKE suite identifier.
+func (h HPKE) suite() []byte {
+	id := make([]byte, 6)
+	binary.BigEndian.PutUint16(id[0:], h.kem.ID())
+	binary.BigEndian.PutUint16(id[2:], h.kdf.id)
+	binary.BigEndian.PutUint16(id[4:], h.aead.id)
+	return primitives.Concat([]byte("HPKE"), id)
+}
+
+func (h HPKE) labeledExtract(salt []byte, label string, ikm []byte) ([]byte, error) {
+	return labeledExtract(h.kdf.hash, h.suite(), salt, label, ikm)
+}
+
+func (h HPKE) labeledExpand(prk []byte, label string, info []byte, n int) ([]byte, error) {
+	return labeledExpand(h.kdf.hash, h.suite(), prk, label, info, n)
+}
+
+// seal encrypts a message under the next nonce of the context.
+func (c *hpkeContext) seal(ad, msg []byte) ([]byte, error) {
+	ct := c.aead.Seal(nil, c.next(), msg, ad)
+	c.seq++
+	return ct, nil
+}
+
+// open decrypts a ciphertext under the next nonce of the context.
+func (c *hpkeContext) open(ad, ct []byte) ([]byte, error) {
+	msg, err := c.aead.Open(nil, c.next(), ct, ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decrypt ciphertext")
+	}
+	c.seq++
+	return msg, nil
+}
+
+// export derives a secret of a given length from the context.
+func (c *hpkeContext) export(context []byte, n int) ([]byte, error) {
+	return c.hpke.labeledExpand(c.exporter, "sec", context, n)
+}
+
+// next computes the nonce of the current sequence number.
+func (c *hpkeContext) next() []byte {
+	var seq [nonceSize]byte
+	binary.BigEndian.PutUint64(seq[nonceSize-8:], c.seq)
+	return primitives.Xor(c.nonce, seq[:])
+}
+
+// newGCM creates an AES-GCM AEAD instance.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,74 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/mlkem"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// MLKEM768 implements the ML-KEM-768 key-encapsulation mechanism (FIPS 203). Private keys
+// are stored in their 64-byte seed form.
+type MLKEM768 struct{}
+
+// NewMLKEM768 creates a fresh ML-KEM-768 instance.
+func NewMLKEM768() *MLKEM768 {
+	return &MLKEM768{}
+}
+
+// ID returns the KEM identifier of ML-KEM-768 in HPKE.
+func (m MLKEM768) ID() uint16 {
+	return 0x0041
+}
+
+// EncapsulationSize returns the size of an encapsulation in bytes.
+func (m MLKEM768) EncapsulationSize() int {
+	return mlkem.CiphertextSize768
+}
+
+// Generate creates a public/private key pair. If seed is nil crypto/rand is used as the
+// random stream, otherwise the seed is expanded deterministically.
+func (m MLKEM768) Generate(seed []byte) (pk, sk []byte, err error) {
+	d := make([]byte, mlkem.SeedSize)
+	if _, err := io.ReadFull(primitives.NewDRBG(seed, "ml-kem-768"), d); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to poll random source")
+	}
+	return m.derive(d)
+}
+
+// Encapsulate generates and encapsulates a fresh symmetric key under a public key.
+func (m MLKEM768) Encapsulate(pk []byte) (k, c []byte, err error) {
+	public, err := mlkem.NewEncapsulationKey768(pk)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to decode ml-kem public key")
+	}
+	k, c = public.Encapsulate()
+	return k, c, nil
+}
+
+// Decapsulate decapsulates a symmetric key from a ciphertext with a private key.
+func (m MLKEM768) Decapsulate(sk, ct []byte) ([]byte, error) {
+	private, err := mlkem.NewDecapsulationKey768(sk)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode ml-kem private key")
+	}
+	k, err := private.Decapsulate(ct)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decapsulate key")
+	}
+	return k, nil
+}
+
+// derive creates a key pair from a 64-byte FIPS 203 seed.
+func (m MLKEM768) derive(d []byte) (pk, sk []byte, err error) {
+	private, err := mlkem.NewDecapsulationKey768(d)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create ml-kem key pair")
+	}
+	return private.EncapsulationKey().Bytes(), private.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+//go:build go1.26
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/mlkem"
+	"crypto/mlkem/mlkemtest"
+	"crypto/sha3"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The known-answer test relies on crypto/mlkem/mlkemtest, which is only available from
+// Go 1.26 on, and is thus kept apart from the other ML-KEM tests.
+
+// TestMLKEM768_Accumulated derives 100 key pairs and encapsulations from a SHAKE-128 stream
+// and compares the hash of all outputs with the accumulated FIPS 203 known-answer value
+// of the C2SP/CCTV project. It also covers the implicit rejection of invalid ciphertexts.
+func TestMLKEM768_Accumulated(t *testing.T) {
+	require := require.New(t)
+
+	kem := NewMLKEM768()
+
+	s := sha3.NewSHAKE128()
+	o := sha3.NewSHAKE128()
+
+	seed := make([]byte, mlkem.SeedSize)
+	msg := make([]byte, 32)
+	ct := make([]byte, mlkem.CiphertextSize768)
+
+	for i := 0; i < 100; i++ {
+		s.Read(seed)
+		pk, sk, err := kem.derive(seed)
+		require.Nil(err)
+		o.Write(pk)
+
+		ek, err := mlkem.NewEncapsulationKey768(pk)
+		require.Nil(err)
+
+		s.Read(msg)
+		k, c, err := mlkemtest.Encapsulate768(ek, msg)
+		require.Nil(err)
+		o.Write(c)
+		o.Write(k)
+
+		kk, err := kem.Decapsulate(sk, c)
+		require.Nil(err)
+		require.True(bytes.Equal(k, kk))
+
+		s.Read(ct)
+		k, err = kem.Decapsulate(sk, ct)
+		require.Nil(err)
+		o.Write(k)
+	}
+
+	sum := make([]byte, 32)
+	o.Read(sum)
+
+	expected := "1114b1b6699ed191734fa339376afa7e285c9e6acf6ff0177d346696ce564415"
+	require.Equal(expected, hex.EncodeToString(sum))
+}
@@ -0,0 +1,38 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMLKEM768(t *testing.T) {
+	require := require.New(t)
+
+	kem := NewMLKEM768()
+
+	pk, sk, err := kem.Generate(nil)
+	require.Nil(err)
+
+	ka, c, err := kem.Encapsulate(pk)
+	require.Nil(err)
+
+	kb, err := kem.Decapsulate(sk, c)
+	require.Nil(err)
+	require.True(bytes.Equal(ka, kb))
+
+	seed := []byte("seed")
+	pk1, sk1, err := kem.Generate(seed)
+	require.Nil(err)
+	pk2, sk2, err := kem.Generate(seed)
+	require.Nil(err)
+	require.True(bytes.Equal(pk1, pk2))
+	require.True(bytes.Equal(sk1, sk2))
+
+	_, err = kem.Decapsulate(sk, c[:len(c)-1])
+	require.NotNil(err)
+}
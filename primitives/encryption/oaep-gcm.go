@@ -0,0 +1,79 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+)
+
+// oaepGCMKeySize is the size of the AES-GCM key transported with RSA-OAEP.
+const oaepGCMKeySize = 16
+
+// OAEPGCM implements a hybrid encryption scheme in which a fresh AES-GCM key is
+// transported with RSA-OAEP. Contrary to plain RSA-OAEP it supports messages of arbitrary
+// length such that it can be used wherever ECIES is used in the protocols.
+type OAEPGCM struct {
+	oaep *OAEP
+	gcm  *GCM
+}
+
+// NewOAEPGCM creates a fresh RSA-OAEP/AES-GCM instance whose keys have a modulus of the
+// given size in bits. The same sizes as for NewOAEP are supported.
+func NewOAEPGCM(bits int) (*OAEPGCM, error) {
+	oaep, err := NewOAEP(bits)
+	if err != nil {
+		return nil, err
+	}
+	return &OAEPGCM{oaep: oaep, gcm: NewGCM()}, nil
+}
+
+// Generate creates a fresh RSA public/private key pair. If seed is nil crypto/rand is used
+// as the random stream, otherwise the seed is expanded deterministically.
+func (o OAEPGCM) Generate(seed []byte) (pk, sk []byte, err error) {
+	return o.oaep.Generate(seed)
+}
+
+// Encrypt enciphers a message and associated data with the given public key. The
+// ciphertext consists of the RSA-OAEP encrypted key followed by the AES-GCM ciphertext.
+func (o OAEPGCM) Encrypt(pk, msg, ad []byte) ([]byte, error) {
+	key := make([]byte, oaepGCMKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "unable to generate aes-gcm key")
+	}
+
+	wrapped, err := o.oaep.Encrypt(pk, key, ad)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := o.gcm.Encrypt(key, msg, ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encrypt message")
+	}
+	return append(wrapped, ct...), nil
+}
+
+// Decrypt deciphers a ciphertext and associated data with the given private key.
+func (o OAEPGCM) Decrypt(sk, ct, ad []byte) ([]byte, error) {
+	private, err := x509.ParsePKCS1PrivateKey(sk)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal rsa-oaep private key")
+	}
+	size := private.Size()
+	if len(ct) < size {
+		return nil, errors.Errorf("invalid ciphertext size: %d", len(ct))
+	}
+
+	key, err := o.oaep.Decrypt(sk, ct[:size], ad)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := o.gcm.Decrypt(key, ct[size:], ad)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decrypt ciphertext")
+	}
+	return msg, nil
+}
@@ -0,0 +1,42 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+func TestOAEPGCM(t *testing.T) {
+	require := require.New(t)
+
+	oaep, err := NewOAEPGCM(primitives.RSA2048)
+	require.Nil(err)
+
+	// The message exceeds the RSA-OAEP capacity of a 2048-bit modulus.
+	msg := bytes.Repeat([]byte("rsa-oaep"), 100)
+	ad := []byte("associated-data")
+
+	pk, sk, err := oaep.Generate(nil)
+	require.Nil(err)
+
+	ct, err := oaep.Encrypt(pk, msg, ad)
+	require.Nil(err)
+
+	pt, err := oaep.Decrypt(sk, ct, ad)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	_, err = oaep.Decrypt(sk, ct, []byte("ad"))
+	require.NotNil(err)
+	_, err = oaep.Decrypt(sk, ct[:100], ad)
+	require.NotNil(err)
+
+	_, err = NewOAEPGCM(1024)
+	require.NotNil(err)
+}
@@ -8,34 +8,41 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
-	"encoding/binary"
 	"io"
-	mr "math/rand"
+	"math/big"
 
 	"github.com/pkg/errors"
+
+	"github.com/qantik/ratcheted/primitives"
 )
 
-var oaepKeySize = 2500
+// oaepExponent is the public RSA exponent.
+const oaepExponent = 65537
 
 // OAEP implements to RSA-OAEP encryption scheme based on SHA256.
-type OAEP struct{}
+type OAEP struct {
+	bits int
+}
 
-// NewOAEP creates a fresh RSA-OAEP instance.
-func NewOAEP() *OAEP {
-	return &OAEP{}
+// NewOAEP creates a fresh RSA-OAEP instance whose keys have a modulus of the given size
+// in bits. Only the sizes primitives.RSA2048, primitives.RSA3072 and primitives.RSA4096 are
+// supported.
+func NewOAEP(bits int) (*OAEP, error) {
+	if err := primitives.CheckRSASize(bits); err != nil {
+		return nil, err
+	}
+	return &OAEP{bits: bits}, nil
 }
 
-// Generate creates a fresh RSA-OAEP public/private key pair.
+// Generate creates a fresh RSA-OAEP public/private key pair. If seed is nil crypto/rand
+// is used as the random stream, otherwise the seed is expanded deterministically.
 func (o OAEP) Generate(seed []byte) (pk, sk []byte, err error) {
-	var reader io.Reader
+	var private *rsa.PrivateKey
 	if seed == nil {
-		reader = rand.Reader
+		private, err = rsa.GenerateKey(rand.Reader, o.bits)
 	} else {
-		// TODO: Find a more secure way to have deterministic streams.
-		reader = mr.New(mr.NewSource(int64(binary.BigEndian.Uint64(seed))))
+		private, err = o.derive(primitives.NewDRBG(seed, "oaep"), o.bits)
 	}
-
-	private, err := rsa.GenerateKey(reader, oaepKeySize)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "unable to generate rsa-oaep key pair")
 	}
@@ -73,3 +80,68 @@ func (o OAEP) Decrypt(sk, ct, ad []byte) ([]byte, error) {
 	}
 	return msg, nil
 }
+
+// derive deterministically creates a RSA private key from a random stream. The standard
+// library key generation cannot be used here since it purposely consumes a variable amount
+// of randomness.
+func (o OAEP) derive(reader io.Reader, bits int) (*rsa.PrivateKey, error) {
+	one := big.NewInt(1)
+	e := big.NewInt(oaepExponent)
+
+	prime := func(bits int) (*big.Int, error) {
+		b := make([]byte, (bits+7)/8)
+		for {
+			if _, err := io.ReadFull(reader, b); err != nil {
+				return nil, err
+			}
+			// Clear excess bits and set the two most significant ones such that the
+			// product of two primes has the full size.
+			b[0] &= byte(0xff >> uint(len(b)*8-bits))
+			p := new(big.Int).SetBytes(b)
+			p.SetBit(p, bits-1, 1)
+			p.SetBit(p, bits-2, 1)
+			p.SetBit(p, 0, 1)
+
+			for ; p.BitLen() == bits; p.Add(p, big.NewInt(2)) {
+				if !p.ProbablyPrime(20) {
+					continue
+				}
+				if new(big.Int).GCD(nil, nil, e, new(big.Int).Sub(p, one)).Cmp(one) == 0 {
+					return p, nil
+				}
+			}
+		}
+	}
+
+	for {
+		p, err := prime(bits - bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := prime(bits / 2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		private := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: oaepExponent},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := private.Validate(); err != nil {
+			return nil, err
+		}
+		private.Precompute()
+		return private, nil
+	}
+}
@@ -6,15 +6,19 @@ package encryption
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/x509"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives"
 )
 
 func TestOAEP(t *testing.T) {
 	require := require.New(t)
 
-	oaep := NewOAEP()
+	oaep, err := NewOAEP(primitives.RSA2048)
+	require.Nil(err)
 
 	msg := []byte("rsa-oaep")
 	ad := []byte("associated-data")
@@ -32,3 +36,43 @@ func TestOAEP(t *testing.T) {
 	require.Nil(err)
 	require.True(bytes.Equal(msg, pt))
 }
+
+func TestOAEP_Generate(t *testing.T) {
+	require := require.New(t)
+
+	oaep, err := NewOAEP(primitives.RSA2048)
+	require.Nil(err)
+
+	seed := []byte("seed")
+
+	pk1, sk1, err := oaep.Generate(seed)
+	require.Nil(err)
+	pk2, sk2, err := oaep.Generate(seed)
+	require.Nil(err)
+	require.True(bytes.Equal(pk1, pk2))
+	require.True(bytes.Equal(sk1, sk2))
+
+	pk2, _, err = oaep.Generate([]byte("deed"))
+	require.Nil(err)
+	require.False(bytes.Equal(pk1, pk2))
+}
+
+func TestOAEP_KeySize(t *testing.T) {
+	require := require.New(t)
+
+	_, err := NewOAEP(2500)
+	require.NotNil(err)
+
+	oaep, err := NewOAEP(primitives.RSA3072)
+	require.Nil(err)
+	pk, sk, err := oaep.Generate([]byte("seed"))
+	require.Nil(err)
+
+	public, err := x509.ParsePKCS1PublicKey(pk)
+	require.Nil(err)
+	require.Equal(primitives.RSA3072, public.N.BitLen())
+
+	private, err := x509.ParsePKCS1PrivateKey(sk)
+	require.Nil(err)
+	require.Equal(primitives.RSA3072, private.N.BitLen())
+}
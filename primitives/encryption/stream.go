@@ -0,0 +1,238 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// StreamSegmentSize is the number of plaintext bytes that are encrypted per segment.
+const StreamSegmentSize = 1 << 16
+
+// streamSaltSize is the size of the random salt that prefixes each stream.
+const streamSaltSize = 16
+
+// ErrStreamTruncated is returned when a stream ends before its final segment.
+var ErrStreamTruncated = errors.New("stream has been truncated")
+
+// StreamWriter encrypts a stream of data with the STREAM construction of Hoang et al.
+// [Online Authenticated-Encryption and its Nonce-Reuse Misuse-Resistance] over AES-GCM.
+//
+// The key is typically a one-time message key output by one of the ratchets such that
+// only the short key has to be sent over the ratchet itself. A random salt is written
+// first from which a fresh AES-256-GCM key is derived. The plaintext is then split into
+// segments that are authenticated under a nonce made of the segment counter and a flag
+// that marks the final segment. This way reordered, dropped and truncated segments are
+// detected by the reader.
+type StreamWriter struct {
+	w    io.Writer
+	aead cipher.AEAD
+	ad   []byte
+
+	buf  []byte
+	size int
+	seq  uint32
+	err  error
+}
+
+// StreamReader decrypts a stream that has been produced by a StreamWriter.
+type StreamReader struct {
+	r    *bufio.Reader
+	aead cipher.AEAD
+	ad   []byte
+
+	buf  []byte
+	seg  []byte
+	size int
+	seq  uint32
+	done bool
+	err  error
+}
+
+// NewStreamWriter creates a stream writer that encrypts to w under a given key and
+// associated data. Close has to be called to write the final segment.
+func NewStreamWriter(w io.Writer, key, ad []byte) (*StreamWriter, error) {
+	return newStreamWriter(w, key, ad, StreamSegmentSize)
+}
+
+// NewStreamReader creates a stream reader that decrypts from r under a given key and
+// associated data.
+func NewStreamReader(r io.Reader, key, ad []byte) (*StreamReader, error) {
+	return newStreamReader(r, key, ad, StreamSegmentSize)
+}
+
+func newStreamWriter(w io.Writer, key, ad []byte, size int) (*StreamWriter, error) {
+	salt := make([]byte, streamSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(salt); err != nil {
+		return nil, err
+	}
+	return &StreamWriter{w: w, aead: aead, ad: ad, buf: make([]byte, 0, size), size: size}, nil
+}
+
+func newStreamReader(r io.Reader, key, ad []byte, size int) (*StreamReader, error) {
+	salt := make([]byte, streamSaltSize)
+	if _, err := io.ReadFull(r, salt); err != nil {
+		return nil, ErrStreamTruncated
+	}
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamReader{
+		r: bufio.NewReader(r), aead: aead, ad: ad,
+		seg: make([]byte, size+aead.Overhead()), size: size,
+	}, nil
+}
+
+// Write encrypts p and writes all completed segments to the underlying writer.
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// A full segment is only flushed once more data arrives, as the final segment
+		// has to be sealed differently.
+		if len(s.buf) == s.size {
+			if s.err = s.flush(false); s.err != nil {
+				return n, s.err
+			}
+		}
+
+		m := s.size - len(s.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		s.buf = append(s.buf, p[:m]...)
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close writes the final segment. It does not close the underlying writer.
+func (s *StreamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.err = s.flush(true)
+	if s.err == nil {
+		s.err = errors.New("stream has been closed")
+		return nil
+	}
+	return s.err
+}
+
+// flush seals and writes the buffered segment.
+func (s *StreamWriter) flush(last bool) error {
+	if s.seq == math.MaxUint32 {
+		return errors.New("stream exceeds maximum number of segments")
+	}
+
+	ct := s.aead.Seal(nil, streamNonce(s.seq, last), s.buf, s.ad)
+	s.seq++
+	s.buf = s.buf[:0]
+
+	_, err := s.w.Write(ct)
+	return err
+}
+
+// Read decrypts the next bytes of the stream into p. Data is only released once the
+// segment it belongs to has been authenticated.
+func (s *StreamReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.err = s.next()
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// next reads and authenticates the next segment.
+func (s *StreamReader) next() error {
+	n, err := io.ReadFull(s.r, s.seg)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if n < s.aead.Overhead() {
+		return ErrStreamTruncated
+	}
+
+	// A segment is the final one if no further data follows.
+	last := n < len(s.seg)
+	if !last {
+		if _, err := s.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if s.seq == math.MaxUint32 {
+		return errors.New("stream exceeds maximum number of segments")
+	}
+
+	pt, err := s.aead.Open(nil, streamNonce(s.seq, last), s.seg[:n], s.ad)
+	if err != nil {
+		// An intermediate segment at the end of the stream indicates truncation.
+		if _, e := s.aead.Open(nil, streamNonce(s.seq, false), s.seg[:n], s.ad); last && e == nil {
+			return ErrStreamTruncated
+		}
+		return errors.New("failed to authenticate segment")
+	}
+	s.seq++
+
+	s.buf = pt
+	s.done = last
+	return nil
+}
+
+// streamAEAD derives the AES-256-GCM instance of a stream from the key and the salt.
+func streamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	k := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte("stream")), k); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// streamNonce encodes the segment counter and the final segment flag into a nonce.
+func streamNonce(seq uint32, last bool) []byte {
+	nonce := make([]byte, nonceSize)
+	binary.BigEndian.PutUint32(nonce[nonceSize-5:], seq)
+	if last {
+		nonce[nonceSize-1] = 1
+	}
+	return nonce
+}
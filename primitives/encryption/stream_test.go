@@ -0,0 +1,129 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// streamTestSegmentSize is a small segment size that makes tests span many segments.
+const streamTestSegmentSize = 16
+
+func TestStream(t *testing.T) {
+	require := require.New(t)
+
+	key, ad := make([]byte, 16), []byte("ad")
+
+	for _, n := range []int{0, 1, 15, 16, 17, 48, 1000} {
+		msg := make([]byte, n)
+		rand.Read(msg)
+
+		ct := streamEncrypt(t, key, ad, msg)
+		segments := (n + streamTestSegmentSize - 1) / streamTestSegmentSize
+		if segments == 0 {
+			segments = 1
+		}
+		require.Equal(streamSaltSize+n+segments*16, len(ct))
+
+		pt, err := streamDecrypt(key, ad, ct)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		_, err = streamDecrypt(key, []byte("da"), ct)
+		require.NotNil(err)
+	}
+
+	// Writes of arbitrary chunk sizes yield the same plaintext.
+	msg := make([]byte, 100)
+	rand.Read(msg)
+
+	var buf bytes.Buffer
+	w, err := newStreamWriter(&buf, key, ad, streamTestSegmentSize)
+	require.Nil(err)
+	for i := 0; i < len(msg); i += 7 {
+		end := i + 7
+		if end > len(msg) {
+			end = len(msg)
+		}
+		_, err := w.Write(msg[i:end])
+		require.Nil(err)
+	}
+	require.Nil(w.Close())
+
+	pt, err := streamDecrypt(key, ad, buf.Bytes())
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+}
+
+func TestStream_Tampering(t *testing.T) {
+	require := require.New(t)
+
+	key, ad := make([]byte, 16), []byte("ad")
+
+	msg := make([]byte, 3*streamTestSegmentSize+5)
+	rand.Read(msg)
+
+	ct := streamEncrypt(t, key, ad, msg)
+	seg := streamTestSegmentSize + 16
+
+	segment := func(i int) []byte {
+		return ct[streamSaltSize+i*seg : streamSaltSize+(i+1)*seg]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	// Drop the final segment.
+	_, err := streamDecrypt(key, ad, ct[:streamSaltSize+3*seg])
+	require.Equal(ErrStreamTruncated, err)
+
+	// Cut the stream in the middle of a segment.
+	_, err = streamDecrypt(key, ad, ct[:streamSaltSize+seg+5])
+	require.NotNil(err)
+
+	// Drop the salt.
+	_, err = streamDecrypt(key, ad, ct[:5])
+	require.Equal(ErrStreamTruncated, err)
+
+	// Swap two segments.
+	reordered := join(ct[:streamSaltSize], segment(1), segment(0), ct[streamSaltSize+2*seg:])
+	_, err = streamDecrypt(key, ad, reordered)
+	require.NotNil(err)
+
+	// Drop an intermediate segment.
+	dropped := join(ct[:streamSaltSize], segment(0), ct[streamSaltSize+2*seg:])
+	_, err = streamDecrypt(key, ad, dropped)
+	require.NotNil(err)
+
+	// Flip a bit.
+	tampered := append([]byte{}, ct...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = streamDecrypt(key, ad, tampered)
+	require.NotNil(err)
+}
+
+func streamEncrypt(t *testing.T, key, ad, msg []byte) []byte {
+	var buf bytes.Buffer
+
+	w, err := newStreamWriter(&buf, key, ad, streamTestSegmentSize)
+	require.Nil(t, err)
+	_, err = w.Write(msg)
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func streamDecrypt(key, ad, ct []byte) ([]byte, error) {
+	r, err := newStreamReader(bytes.NewReader(ct), key, ad, streamTestSegmentSize)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
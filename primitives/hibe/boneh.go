@@ -6,6 +6,8 @@ package hibe
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
+	"io"
 
 	"github.com/Nik-U/pbc"
 	"github.com/qantik/ratcheted/primitives"
@@ -14,13 +16,18 @@ import (
 // maxDepth is the limit to how deep a Boneh hierarchy can reach.
 const maxDepth = 10
 
-// Boneh designates a Boneh-Boyen-Goh protocol instance.
-type Boneh struct{}
+// Boneh designates a Boneh-Boyen-Goh protocol instance. In case of an asymmetric pairing,
+// G, G1, A1 and V lie in G1 whereas all other elements lie in G2.
+type Boneh struct {
+	pairing *Pairing
+}
 
 // bonehParams composes the public parameters of a protocol instance.
 type bonehParams struct {
 	G, G1, G2, G3 *pbc.Element           // G, G1, G2, G3 are generator elements.
 	H             [maxDepth]*pbc.Element // H are blinding factors.
+
+	Pairing string // Pairing identifies the pairing, see Pairing.id.
 }
 
 // bonehEntity designates a participant in the protocol. It can be both the root PKG,
@@ -45,43 +52,66 @@ type bonehCiphertext struct {
 	V, W *pbc.Element
 }
 
-// NewBoneh creates a fresh protocol instance over a symmetric pairing.
-func NewBoneh() *Boneh {
-	return &Boneh{}
+// NewBoneh creates a fresh protocol instance. Without options the fixed BNP256 pairing is
+// used.
+func NewBoneh(opts ...Option) *Boneh {
+	return &Boneh{pairing: newPairing(opts)}
+}
+
+// NewBonehFromParams creates a protocol instance for the pairing of public parameters as
+// returned by Setup.
+func NewBonehFromParams(params []byte) (*Boneh, error) {
+	var packet bonehParamsPacket
+	if err := json.Unmarshal(params, &packet); err != nil {
+		return nil, err
+	}
+	pairing, err := pairingFromID(packet.Pairing)
+	if err != nil {
+		return nil, err
+	}
+	return &Boneh{pairing: pairing}, nil
+}
+
+// MaxDepth returns the maximum depth of a Boneh hierarchy.
+func (b Boneh) MaxDepth() int {
+	return maxDepth
 }
 
-// Setup establishes the public parameters and generates a root entity PKG.
+// Setup establishes the public parameters and generates a root entity PKG. All elements
+// are sampled from the expanded seed, a nil seed yields fresh randomness.
 func (b Boneh) Setup(seed []byte) (params, root []byte, err error) {
-	// In order to reuse the same seed for sampling multiple elements the seed is
-	// iteratively hashed.
-	hash := func(base []byte, n int) []byte {
-		digest := seed
-		for i := 0; i < n; i++ {
-			digest = primitives.Digest(sha256.New(), digest)
+	reader := primitives.NewDRBG(seed, "boneh")
+
+	// The samples are consumed in the order G, alpha, G2, G3, r, H.
+	var s [5 + maxDepth][sampleSize]byte
+	for i := range s {
+		if _, err = io.ReadFull(reader, s[i][:]); err != nil {
+			return
 		}
-		return digest
 	}
 
-	G := pairing.NewG1().SetFromHash(hash(seed, 1))
-	alpha := pairing.NewZr().SetFromHash(hash(seed, 2))
+	pairing := b.pairing.pairing
+
+	G := pairing.NewG1().SetFromHash(s[0][:])
+	alpha := pairing.NewZr().SetFromHash(s[1][:])
 
 	G1 := pairing.NewG1().MulZn(G, alpha)
-	G2 := pairing.NewG1().SetFromHash(hash(seed, 3))
-	G3 := pairing.NewG1().SetFromHash(hash(seed, 4))
+	G2 := pairing.NewG2().SetFromHash(s[2][:])
+	G3 := pairing.NewG2().SetFromHash(s[3][:])
 
-	r := pairing.NewZr().SetFromHash(hash(seed, 5))
-	A0 := pairing.NewG1().MulZn(G2, alpha)
-	A0 = pairing.NewG1().Add(A0, pairing.NewG1().MulZn(G3, r))
+	r := pairing.NewZr().SetFromHash(s[4][:])
+	A0 := pairing.NewG2().MulZn(G2, alpha)
+	A0 = pairing.NewG2().Add(A0, pairing.NewG2().MulZn(G3, r))
 	A1 := pairing.NewG1().MulZn(G, r)
 
 	var H [maxDepth]*pbc.Element
 	var B [maxDepth]*pbc.Element
 	for i := 0; i < maxDepth; i++ {
-		H[i] = pairing.NewG1().SetFromHash(hash(seed, i+6))
-		B[i] = pairing.NewG1().MulZn(H[i], r)
+		H[i] = pairing.NewG2().SetFromHash(s[5+i][:])
+		B[i] = pairing.NewG2().MulZn(H[i], r)
 	}
 
-	p := &bonehParams{G: G, G1: G1, G2: G2, G3: G3, H: H}
+	p := &bonehParams{G: G, G1: G1, G2: G2, G3: G3, H: H, Pairing: b.pairing.id()}
 	params, err = p.MarshalJSON()
 	if err != nil {
 		return
@@ -94,31 +124,35 @@ func (b Boneh) Setup(seed []byte) (params, root []byte, err error) {
 // Extract generates a fresh child entity specified by id from an ancestor entity.
 func (b Boneh) Extract(ancestor, id []byte) ([]byte, error) {
 	var e bonehEntity
-	if err := e.UnmarshalJSON(ancestor); err != nil {
+	if err := e.decode(b.pairing, ancestor); err != nil {
 		return nil, err
 	}
+	if len(e.ID) >= maxDepth {
+		return nil, errors.New("entity has reached maximum hierarchy depth")
+	}
+	pairing := b.pairing.pairing
 
 	childID := append(e.ID, id)
 	k := len(childID)
 	t := pairing.NewZr().Rand()
 
-	h := pairing.NewG1().Set1()
+	h := pairing.NewG2().Set1()
 	for i := 0; i < k; i++ {
 		j := pairing.NewZr().SetFromStringHash(string(childID[i]), sha256.New())
-		h = pairing.NewG1().Add(h, pairing.NewG1().MulZn(e.H[i], j))
+		h = pairing.NewG2().Add(h, pairing.NewG2().MulZn(e.H[i], j))
 	}
-	h = pairing.NewG1().Add(h, e.G3)
-	h = pairing.NewG1().MulZn(h, t)
+	h = pairing.NewG2().Add(h, e.G3)
+	h = pairing.NewG2().MulZn(h, t)
 
 	ik := pairing.NewZr().SetFromStringHash(string(id), sha256.New())
-	A0 := pairing.NewG1().Add(e.A0, pairing.NewG1().MulZn(e.B[0], ik))
-	A0 = pairing.NewG1().Add(A0, h)
+	A0 := pairing.NewG2().Add(e.A0, pairing.NewG2().MulZn(e.B[0], ik))
+	A0 = pairing.NewG2().Add(A0, h)
 
 	A1 := pairing.NewG1().Add(e.A1, pairing.NewG1().MulZn(e.G, t))
 
 	var B []*pbc.Element
 	for i := k; i < maxDepth; i++ {
-		B = append(B, pairing.NewG1().Add(e.B[i-k+1], pairing.NewG1().MulZn(e.H[i], t)))
+		B = append(B, pairing.NewG2().Add(e.B[i-k+1], pairing.NewG2().MulZn(e.H[i], t)))
 	}
 
 	child := &bonehEntity{ID: childID, G: e.G, G3: e.G3, H: e.H, A0: A0, A1: A1, B: B}
@@ -128,10 +162,15 @@ func (b Boneh) Extract(ancestor, id []byte) ([]byte, error) {
 // Encrypt encrypts a messages for a given id. Note, that the ciphertext is split into two
 // parts to simplify the integration in other protocols.
 func (b Boneh) Encrypt(params, message []byte, id [][]byte) (c1, c2 []byte, err error) {
+	if len(id) > maxDepth {
+		return nil, nil, errors.New("id exceeds maximum hierarchy depth")
+	}
+
 	var p bonehParams
-	if err := p.UnmarshalJSON(params); err != nil {
+	if err := p.decode(b.pairing, params); err != nil {
 		return nil, nil, err
 	}
+	pairing := b.pairing.pairing
 
 	s := pairing.NewZr().Rand()
 	h := pairing.NewGT().MulZn(pairing.NewGT().Pair(p.G1, p.G2), s).Bytes()
@@ -139,13 +178,13 @@ func (b Boneh) Encrypt(params, message []byte, id [][]byte) (c1, c2 []byte, err
 
 	V := pairing.NewG1().MulZn(p.G, s)
 
-	W := pairing.NewG1().Set1()
+	W := pairing.NewG2().Set1()
 	for i := 0; i < len(id); i++ {
 		j := pairing.NewZr().SetFromStringHash(string(id[i]), sha256.New())
-		W = pairing.NewG1().Add(W, pairing.NewG1().MulZn(p.H[i], j))
+		W = pairing.NewG2().Add(W, pairing.NewG2().MulZn(p.H[i], j))
 	}
-	W = pairing.NewG1().Add(W, p.G3)
-	W = pairing.NewG1().MulZn(W, s)
+	W = pairing.NewG2().Add(W, p.G3)
+	W = pairing.NewG2().MulZn(W, s)
 
 	ct := bonehCiphertext{V: V, W: W}
 	c2, err = ct.MarshalJSON()
@@ -155,14 +194,15 @@ func (b Boneh) Encrypt(params, message []byte, id [][]byte) (c1, c2 []byte, err
 // Decrypt decrypts a given ciphertext using the secret key material of an entity.
 func (b Boneh) Decrypt(entity, c1, c2 []byte) ([]byte, error) {
 	var e bonehEntity
-	if err := e.UnmarshalJSON(entity); err != nil {
+	if err := e.decode(b.pairing, entity); err != nil {
 		return nil, err
 	}
 
 	var c bonehCiphertext
-	if err := c.UnmarshalJSON(c2); err != nil {
+	if err := c.decode(b.pairing, c2); err != nil {
 		return nil, err
 	}
+	pairing := b.pairing.pairing
 
 	n := pairing.NewGT().Pair(e.A1, c.W)
 	d := pairing.NewGT().Pair(c.V, e.A0)
@@ -173,6 +213,7 @@ func (b Boneh) Decrypt(entity, c1, c2 []byte) ([]byte, error) {
 type bonehParamsPacket struct {
 	G, G1, G2, G3 []byte
 	H             [][]byte
+	Pairing       string
 }
 
 func (p bonehParams) MarshalJSON() ([]byte, error) {
@@ -181,6 +222,8 @@ func (p bonehParams) MarshalJSON() ([]byte, error) {
 		G1: p.G1.CompressedBytes(),
 		G2: p.G2.CompressedBytes(),
 		G3: p.G3.CompressedBytes(),
+
+		Pairing: p.Pairing,
 	}
 	for _, h := range p.H {
 		packet.H = append(packet.H, h.CompressedBytes())
@@ -188,18 +231,26 @@ func (p bonehParams) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&packet)
 }
 
-func (p *bonehParams) UnmarshalJSON(data []byte) error {
+// decode unmarshals public parameters that have been created for the given pairing.
+func (p *bonehParams) decode(pairing *Pairing, data []byte) error {
 	var packet bonehParamsPacket
 	if err := json.Unmarshal(data, &packet); err != nil {
 		return err
 	}
-	p.G = pairing.NewG1().SetCompressedBytes(packet.G)
-	p.G1 = pairing.NewG1().SetCompressedBytes(packet.G1)
-	p.G2 = pairing.NewG1().SetCompressedBytes(packet.G2)
-	p.G3 = pairing.NewG1().SetCompressedBytes(packet.G3)
+	if err := pairing.check(packet.Pairing); err != nil {
+		return err
+	}
+	if len(packet.H) > maxDepth {
+		return errors.New("invalid number of blinding factors")
+	}
+	p.G = pairing.pairing.NewG1().SetCompressedBytes(packet.G)
+	p.G1 = pairing.pairing.NewG1().SetCompressedBytes(packet.G1)
+	p.G2 = pairing.pairing.NewG2().SetCompressedBytes(packet.G2)
+	p.G3 = pairing.pairing.NewG2().SetCompressedBytes(packet.G3)
 	for i, h := range packet.H {
-		p.H[i] = pairing.NewG1().SetCompressedBytes(h)
+		p.H[i] = pairing.pairing.NewG2().SetCompressedBytes(h)
 	}
+	p.Pairing = packet.Pairing
 	return nil
 }
 
@@ -228,21 +279,25 @@ func (e bonehEntity) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&packet)
 }
 
-func (e *bonehEntity) UnmarshalJSON(data []byte) error {
+// decode unmarshals an entity over the given pairing.
+func (e *bonehEntity) decode(pairing *Pairing, data []byte) error {
 	var packet bonehEntityPacket
 	if err := json.Unmarshal(data, &packet); err != nil {
 		return err
 	}
+	if len(packet.H) > maxDepth {
+		return errors.New("invalid number of blinding factors")
+	}
 	e.ID = packet.ID
-	e.G = pairing.NewG1().SetCompressedBytes(packet.G)
-	e.G3 = pairing.NewG1().SetCompressedBytes(packet.G3)
-	e.A0 = pairing.NewG1().SetCompressedBytes(packet.A0)
-	e.A1 = pairing.NewG1().SetCompressedBytes(packet.A1)
+	e.G = pairing.pairing.NewG1().SetCompressedBytes(packet.G)
+	e.G3 = pairing.pairing.NewG2().SetCompressedBytes(packet.G3)
+	e.A0 = pairing.pairing.NewG2().SetCompressedBytes(packet.A0)
+	e.A1 = pairing.pairing.NewG1().SetCompressedBytes(packet.A1)
 	for _, b := range packet.B {
-		e.B = append(e.B, pairing.NewG1().SetCompressedBytes(b))
+		e.B = append(e.B, pairing.pairing.NewG2().SetCompressedBytes(b))
 	}
 	for i, h := range packet.H {
-		e.H[i] = pairing.NewG1().SetCompressedBytes(h)
+		e.H[i] = pairing.pairing.NewG2().SetCompressedBytes(h)
 	}
 	return nil
 }
@@ -257,12 +312,13 @@ func (e bonehCiphertext) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&packet)
 }
 
-func (e *bonehCiphertext) UnmarshalJSON(data []byte) error {
+// decode unmarshals a ciphertext over the given pairing.
+func (e *bonehCiphertext) decode(pairing *Pairing, data []byte) error {
 	var packet bonehCiphertextPacket
 	if err := json.Unmarshal(data, &packet); err != nil {
 		return err
 	}
-	e.V = pairing.NewG1().SetCompressedBytes(packet.V)
-	e.W = pairing.NewG1().SetCompressedBytes(packet.W)
+	e.V = pairing.pairing.NewG1().SetCompressedBytes(packet.V)
+	e.W = pairing.pairing.NewG2().SetCompressedBytes(packet.W)
 	return nil
 }
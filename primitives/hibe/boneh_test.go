@@ -98,3 +98,86 @@ func TestBoneh(t *testing.T) {
 	require.True(bytes.Equal(msg, pt))
 
 }
+
+func TestBoneh_MaxDepth(t *testing.T) {
+	require := require.New(t)
+
+	b := NewBoneh()
+
+	var seed [128]byte
+	rand.Read(seed[:])
+
+	_, e, err := b.Setup(seed[:])
+	require.Nil(err)
+
+	for i := 0; i < b.MaxDepth(); i++ {
+		e, err = b.Extract(e, []byte{byte(i)})
+		require.Nil(err)
+	}
+
+	_, err = b.Extract(e, []byte{})
+	require.NotNil(err)
+}
+
+func TestBoneh_Setup(t *testing.T) {
+	require := require.New(t)
+
+	boneh := NewBoneh()
+
+	seed := []byte("seed")
+
+	p1, r1, err := boneh.Setup(seed)
+	require.Nil(err)
+	p2, r2, err := boneh.Setup(seed)
+	require.Nil(err)
+	require.True(bytes.Equal(p1, p2))
+	require.True(bytes.Equal(r1, r2))
+
+	p2, r2, err = boneh.Setup([]byte("deed"))
+	require.Nil(err)
+	require.False(bytes.Equal(p1, p2))
+	require.False(bytes.Equal(r1, r2))
+}
+
+func TestBoneh_Pairing(t *testing.T) {
+	require := require.New(t)
+
+	pairing, err := GenerateTypeF(256)
+	require.Nil(err)
+
+	b := NewBoneh(WithPairing(pairing))
+
+	params, root, err := b.Setup(nil)
+	require.Nil(err)
+
+	id := [][]byte{[]byte{1}, []byte{1, 1}}
+	msg := []byte("hello")
+
+	e1, err := b.Extract(root, id[0])
+	require.Nil(err)
+	e11, err := b.Extract(e1, id[1])
+	require.Nil(err)
+
+	c1, c2, err := b.Encrypt(params, msg, id)
+	require.Nil(err)
+
+	pt, err := b.Decrypt(e11, c1, c2)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	// Public parameters of a different pairing are rejected.
+	_, _, err = NewBoneh().Encrypt(params, msg, id)
+	require.NotNil(err)
+
+	// The pairing is restored from the public parameters.
+	restored, err := NewBonehFromParams(params)
+	require.Nil(err)
+	c1, c2, err = restored.Encrypt(params, msg, id)
+	require.Nil(err)
+	pt, err = b.Decrypt(e11, c1, c2)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	_, err = NewBonehFromParams([]byte("{}"))
+	require.NotNil(err)
+}
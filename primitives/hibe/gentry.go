@@ -6,17 +6,24 @@ package hibe
 import (
 	"bytes"
 	"crypto/sha256"
+	"io"
 
 	"github.com/Nik-U/pbc"
 	"github.com/qantik/ratcheted/primitives"
 )
 
-// Gentry designates a Gentry-Silverberg protocol instance.
-type Gentry struct{}
+// Gentry designates a Gentry-Silverberg protocol instance. In case of an asymmetric
+// pairing, the generator P0 and the Q values lie in G1 whereas the identity hashes and
+// the secret point S lie in G2.
+type Gentry struct {
+	pairing *Pairing
+}
 
 // gentryParams composes the public parameters of a protocol instance.
 type gentryParams struct {
 	P0, Q0 *pbc.Element // P0 and Q0 are the generate elements of the protocol instance.
+
+	Pairing string // Pairing identifies the pairing, see Pairing.id.
 }
 
 // gentryEntity designates a participant in the protocol. It can be both the root PKG,
@@ -42,20 +49,46 @@ type gentryCiphertext struct {
 	U []*pbc.Element
 }
 
-// NewGentry creates a fresh protocol instance over symmetric pairing.
-func NewGentry() *Gentry {
-	return &Gentry{}
+// NewGentry creates a fresh protocol instance. Without options the fixed BNP256 pairing is
+// used.
+func NewGentry(opts ...Option) *Gentry {
+	return &Gentry{pairing: newPairing(opts)}
+}
+
+// NewGentryFromParams creates a protocol instance for the pairing of public parameters as
+// returned by Setup.
+func NewGentryFromParams(params []byte) (*Gentry, error) {
+	var packet gentryParamsPacket
+	if err := primitives.Decode(params, &packet); err != nil {
+		return nil, err
+	}
+	pairing, err := pairingFromID(packet.Pairing)
+	if err != nil {
+		return nil, err
+	}
+	return &Gentry{pairing: pairing}, nil
 }
 
-// Setup establishes the public parameters and generates a root entity PKG.
-// FIXME: Make better use of seed for sampling elements.
+// Setup establishes the public parameters and generates a root entity PKG. All elements
+// are sampled from the expanded seed, a nil seed yields fresh randomness.
 func (g Gentry) Setup(seed []byte) (params, root []byte, err error) {
-	P0 := pairing.NewG1().SetFromStringHash(string(seed), sha256.New())
+	reader := primitives.NewDRBG(seed, "gentry")
 
-	s0 := pairing.NewZr().SetFromStringHash(string(seed), sha256.New())
+	var b [2][sampleSize]byte
+	for i := range b {
+		if _, err = io.ReadFull(reader, b[i][:]); err != nil {
+			return
+		}
+	}
+
+	pairing := g.pairing.pairing
+
+	P0 := pairing.NewG1().SetFromHash(b[0][:])
+
+	s0 := pairing.NewZr().SetFromHash(b[1][:])
 	Q0 := pairing.NewG1().MulZn(P0, s0)
 
-	p := &gentryParams{P0: P0, Q0: Q0}
+	p := &gentryParams{P0: P0, Q0: Q0, Pairing: g.pairing.id()}
 	params, err = p.GobEncode()
 	if err != nil {
 		return
@@ -64,7 +97,7 @@ func (g Gentry) Setup(seed []byte) (params, root []byte, err error) {
 	// Set ephemeral secret to the identity element.
 	r := &gentryEntity{
 		ID: [][]byte{},
-		P0: P0, St: s0, S: pairing.NewG1().Set1(),
+		P0: P0, St: s0, S: pairing.NewG2().Set1(),
 		Q: []*pbc.Element{},
 	}
 	root, err = r.GobEncode()
@@ -74,14 +107,15 @@ func (g Gentry) Setup(seed []byte) (params, root []byte, err error) {
 // Extract generates a fresh child entity specified by id from an ancestor entity.
 func (g Gentry) Extract(ancestor []byte, id []byte) ([]byte, error) {
 	var e gentryEntity
-	if err := e.GobDecode(ancestor); err != nil {
+	if err := e.decode(g.pairing, ancestor); err != nil {
 		return nil, err
 	}
+	pairing := g.pairing.pairing
 
 	childID := append(e.ID, id)
 
-	P := pairing.NewG1().SetFromStringHash(string(bytes.Join(childID, nil)), sha256.New())
-	S := pairing.NewG1().Add(e.S, pairing.NewG1().MulZn(P, e.St))
+	P := pairing.NewG2().SetFromStringHash(string(bytes.Join(childID, nil)), sha256.New())
+	S := pairing.NewG2().Add(e.S, pairing.NewG2().MulZn(P, e.St))
 
 	// FIXME: Constant secret should not be generated by the ancestor during key extraction
 	// but at the beginning during the protocol setup.
@@ -100,13 +134,14 @@ func (g Gentry) Extract(ancestor []byte, id []byte) ([]byte, error) {
 // into two parts to simplify the integration in other protocols.
 func (g Gentry) Encrypt(params, message []byte, id [][]byte) (c1, c2 []byte, err error) {
 	var p gentryParams
-	if err = p.GobDecode(params); err != nil {
+	if err = p.decode(g.pairing, params); err != nil {
 		return
 	}
+	pairing := g.pairing.pairing
 
 	P := make([]*pbc.Element, len(id))
 	for i := 0; i < len(id); i++ {
-		P[i] = pairing.NewG1().SetFromStringHash(string(bytes.Join(id[:i+1], nil)), sha256.New())
+		P[i] = pairing.NewG2().SetFromStringHash(string(bytes.Join(id[:i+1], nil)), sha256.New())
 	}
 
 	r := pairing.NewZr().Rand()
@@ -121,7 +156,7 @@ func (g Gentry) Encrypt(params, message []byte, id [][]byte) (c1, c2 []byte, err
 	ct.U[0] = pairing.NewG1().MulZn(p.P0, r)
 	ct.U[1] = nil
 	for i := 2; i <= len(id); i++ {
-		ct.U[i] = pairing.NewG1().MulZn(P[i-1], r)
+		ct.U[i] = pairing.NewG2().MulZn(P[i-1], r)
 	}
 
 	c2, err = ct.GobEncode()
@@ -131,14 +166,15 @@ func (g Gentry) Encrypt(params, message []byte, id [][]byte) (c1, c2 []byte, err
 // Decrypt decrypts a given ciphertext using the secret key material of an entity.
 func (g Gentry) Decrypt(entity, c1, c2 []byte) ([]byte, error) {
 	var e gentryEntity
-	if err := e.GobDecode(entity); err != nil {
+	if err := e.decode(g.pairing, entity); err != nil {
 		return nil, err
 	}
 
 	var c gentryCiphertext
-	if err := c.GobDecode(c2); err != nil {
+	if err := c.decode(g.pairing, c2); err != nil {
 		return nil, err
 	}
+	pairing := g.pairing.pairing
 
 	k := pairing.NewGT().Pair(c.U[0], e.S)
 	for i := 2; i < len(c.U); i++ {
@@ -150,20 +186,26 @@ func (g Gentry) Decrypt(entity, c1, c2 []byte) ([]byte, error) {
 
 // gentryParamsPacket is a helper structure that enables marshalling.
 type gentryParamsPacket struct {
-	P0, Q0 []byte
+	P0, Q0  []byte
+	Pairing string
 }
 
 func (p gentryParams) GobEncode() ([]byte, error) {
-	return primitives.Encode(&gentryParamsPacket{P0: p.P0.Bytes(), Q0: p.Q0.Bytes()})
+	return primitives.Encode(&gentryParamsPacket{P0: p.P0.Bytes(), Q0: p.Q0.Bytes(), Pairing: p.Pairing})
 }
 
-func (p *gentryParams) GobDecode(data []byte) error {
+// decode unmarshals public parameters that have been created for the given pairing.
+func (p *gentryParams) decode(pairing *Pairing, data []byte) error {
 	var packet gentryParamsPacket
 	if err := primitives.Decode(data, &packet); err != nil {
 		return err
 	}
-	p.P0 = pairing.NewG1().SetBytes(packet.P0)
-	p.Q0 = pairing.NewG1().SetBytes(packet.Q0)
+	if err := pairing.check(packet.Pairing); err != nil {
+		return err
+	}
+	p.P0 = pairing.pairing.NewG1().SetBytes(packet.P0)
+	p.Q0 = pairing.pairing.NewG1().SetBytes(packet.Q0)
+	p.Pairing = packet.Pairing
 	return nil
 }
 
@@ -182,18 +224,19 @@ func (e gentryEntity) GobEncode() ([]byte, error) {
 	return primitives.Encode(&packet)
 }
 
-func (e *gentryEntity) GobDecode(data []byte) error {
+// decode unmarshals an entity over the given pairing.
+func (e *gentryEntity) decode(pairing *Pairing, data []byte) error {
 	var packet gentryEntityPacket
 	if err := primitives.Decode(data, &packet); err != nil {
 		return err
 	}
 
 	e.ID = packet.ID
-	e.P0 = pairing.NewG1().SetBytes(packet.P0)
-	e.St = pairing.NewZr().SetBytes(packet.St)
-	e.S = pairing.NewG1().SetBytes(packet.S)
+	e.P0 = pairing.pairing.NewG1().SetBytes(packet.P0)
+	e.St = pairing.pairing.NewZr().SetBytes(packet.St)
+	e.S = pairing.pairing.NewG2().SetBytes(packet.S)
 	for _, q := range packet.Q {
-		e.Q = append(e.Q, pairing.NewG1().SetBytes(q))
+		e.Q = append(e.Q, pairing.pairing.NewG1().SetBytes(q))
 	}
 	return nil
 }
@@ -215,17 +258,22 @@ func (c gentryCiphertext) GobEncode() ([]byte, error) {
 	return primitives.Encode(&packet)
 }
 
-func (c *gentryCiphertext) GobDecode(data []byte) error {
+// decode unmarshals a ciphertext over the given pairing. The first element lies in G1,
+// all others in G2.
+func (c *gentryCiphertext) decode(pairing *Pairing, data []byte) error {
 	var packet gentryCiphertextPacket
 	if err := primitives.Decode(data, &packet); err != nil {
 		return err
 	}
 
-	for _, u := range packet.U {
-		if u != nil {
-			c.U = append(c.U, pairing.NewG1().SetBytes(u))
-		} else {
+	for i, u := range packet.U {
+		switch {
+		case u == nil:
 			c.U = append(c.U, nil)
+		case i == 0:
+			c.U = append(c.U, pairing.pairing.NewG1().SetBytes(u))
+		default:
+			c.U = append(c.U, pairing.pairing.NewG2().SetBytes(u))
 		}
 	}
 	return nil
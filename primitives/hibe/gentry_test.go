@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives"
 )
 
 func TestGentry(t *testing.T) {
@@ -97,3 +99,127 @@ func TestGentry(t *testing.T) {
 	require.Nil(err)
 	require.True(bytes.Equal(msg, pt))
 }
+
+func TestGentry_Setup(t *testing.T) {
+	require := require.New(t)
+
+	gentry := NewGentry()
+
+	seed := []byte("seed")
+
+	p1, r1, err := gentry.Setup(seed)
+	require.Nil(err)
+	p2, r2, err := gentry.Setup(seed)
+	require.Nil(err)
+	require.True(bytes.Equal(p1, p2))
+	require.True(bytes.Equal(r1, r2))
+
+	p2, r2, err = gentry.Setup([]byte("deed"))
+	require.Nil(err)
+	require.False(bytes.Equal(p1, p2))
+	require.False(bytes.Equal(r1, r2))
+}
+
+func TestGentry_Pairing(t *testing.T) {
+	require := require.New(t)
+
+	pairing, err := GenerateTypeF(256)
+	require.Nil(err)
+
+	// Persisted parameters yield the same pairing.
+	pairing, err = NewPairing(pairing.String())
+	require.Nil(err)
+
+	g := NewGentry(WithPairing(pairing))
+
+	params, root, err := g.Setup(nil)
+	require.Nil(err)
+
+	id := [][]byte{[]byte{1}, []byte{1, 1}}
+	msg := []byte("hello")
+
+	e1, err := g.Extract(root, id[0])
+	require.Nil(err)
+	e11, err := g.Extract(e1, id[1])
+	require.Nil(err)
+
+	c1, c2, err := g.Encrypt(params, msg, id)
+	require.Nil(err)
+
+	pt, err := g.Decrypt(e11, c1, c2)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	// Public parameters of a different pairing are rejected.
+	_, _, err = NewGentry().Encrypt(params, msg, id)
+	require.NotNil(err)
+
+	// The pairing is restored from the public parameters.
+	restored, err := NewGentryFromParams(params)
+	require.Nil(err)
+	c1, c2, err = restored.Encrypt(params, msg, id)
+	require.Nil(err)
+	pt, err = g.Decrypt(e11, c1, c2)
+	require.Nil(err)
+	require.True(bytes.Equal(msg, pt))
+
+	_, err = NewGentryFromParams(nil)
+	require.NotNil(err)
+
+	_, err = GenerateTypeF(100)
+	require.NotNil(err)
+	_, err = GenerateTypeF(160)
+	require.NotNil(err)
+	_, err = NewPairing("")
+	require.NotNil(err)
+}
+
+func TestGentry_Preset(t *testing.T) {
+	require := require.New(t)
+
+	id := [][]byte{[]byte{1}}
+	msg := []byte("hello")
+
+	for _, name := range []string{TypeA, BNP256} {
+		pairing, err := PresetPairing(name)
+		require.Nil(err)
+
+		g := NewGentry(WithPairing(pairing))
+		params, root, err := g.Setup(nil)
+		require.Nil(err)
+
+		// Public parameters of a fixed pairing only carry its name.
+		var packet gentryParamsPacket
+		require.Nil(primitives.Decode(params, &packet))
+		require.Equal(name, packet.Pairing)
+
+		restored, err := NewGentryFromParams(params)
+		require.Nil(err)
+		c1, c2, err := restored.Encrypt(params, msg, id)
+		require.Nil(err)
+
+		e1, err := g.Extract(root, id[0])
+		require.Nil(err)
+		pt, err := g.Decrypt(e1, c1, c2)
+		require.Nil(err)
+		require.True(bytes.Equal(msg, pt))
+
+		// Public parameters carrying the full PBC parameters of a fixed pairing are
+		// accepted as well.
+		packet.Pairing = pairing.String()
+		legacy, err := primitives.Encode(&packet)
+		require.Nil(err)
+		_, _, err = g.Encrypt(legacy, msg, id)
+		require.Nil(err)
+	}
+
+	// The default pairing is BNP256.
+	params, _, err := NewGentry().Setup(nil)
+	require.Nil(err)
+	var packet gentryParamsPacket
+	require.Nil(primitives.Decode(params, &packet))
+	require.Equal(BNP256, packet.Pairing)
+
+	_, err = PresetPairing("type-b")
+	require.NotNil(err)
+}
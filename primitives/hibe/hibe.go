@@ -9,14 +9,168 @@
 //
 package hibe
 
-import "github.com/Nik-U/pbc"
+import (
+	"errors"
+	"fmt"
+	"strings"
 
-// pairing specifies the symmetric pairing function on the curve y^2=x^3+x over
-// the finite field F_q of size 512 bits. The resulting group is of size 160 bits.
+	"github.com/Nik-U/pbc"
+)
+
+// Names of the fixed pairings. The public parameters of a HIBE instance over a fixed
+// pairing only carry its name, those over a custom pairing carry its PBC parameters.
+const (
+	// TypeA is the symmetric pairing on the curve y^2=x^3+x over the finite field F_q of
+	// size 512 bits of the default PBC distribution. The resulting group is of size 160
+	// bits, which only offers about 80 bits of security.
+	TypeA = "type-a"
+	// BNP256 is the asymmetric pairing on the Barreto-Naehrig curve BN_P256 of ISO/IEC
+	// 15946-5 and TPM 2.0, y^2=x^3+3 over a field of size 256 bits with u=-0x6882F5C030B0A801.
+	BNP256 = "bn-p256"
+)
+
+// typeAParams are the type A parameters of the default PBC distribution.
+const typeAParams = `type a
+q 8780710799663312522437781984754049815806883199414208211028653399266475630880222957078625179422662221423155858769582317459277713367317481324925129998224791
+h 12016012264891146079388821366740534204802954401251311822919615131047207289359704531102844802183906537786776
+r 730750818665451621361119245571504901405976559617
+exp2 159
+exp1 107
+sign1 1
+sign0 1
+`
+
+// bnP256Params are the type F parameters of BN_P256. F_q^2 is built with the quadratic
+// non-residue beta=-1 and F_q^12 with x^6+alpha for alpha=-(1+sqrt(beta)), for which the
+// sextic twist y^2=x^3-b*alpha has order r(2q-r) and hence contains G2.
+const bnP256Params = `type f
+q 115792089237314936872688561244471742058375878355761205198700409522629664518163
+r 115792089237314936872688561244471742058035595988840268584488757999429535617037
+b 3
+beta 115792089237314936872688561244471742058375878355761205198700409522629664518162
+alpha0 115792089237314936872688561244471742058375878355761205198700409522629664518162
+alpha1 115792089237314936872688561244471742058375878355761205198700409522629664518162
+`
+
+// presets maps the names of the fixed pairings to their PBC parameters.
+var presets = map[string]string{TypeA: typeAParams, BNP256: bnP256Params}
+
+// sampleSize is the number of random bytes that are hashed into a group element.
+const sampleSize = 32
+
+// defaultPairing is the fixed pairing used by HIBE instances created without options.
+// Its parameters are fixed such that marshalled elements remain valid across processes.
 //
-// TODO: Find a way to dynamically create the pairing instead of hard-coding it.
 // TODO: Use point compression to mitigate ciphertext expansion.
-var pairing = pbc.GenerateA(160, 512).NewPairing()
+var defaultPairing = mustPairing(PresetPairing(BNP256))
+
+// Pairing bundles a bilinear pairing with the PBC parameters it has been created from,
+// such that the pairing can be serialised together with the public parameters of a
+// HIBE instance. Both symmetric and asymmetric pairings are supported.
+type Pairing struct {
+	name    string
+	params  string
+	pairing *pbc.Pairing
+}
+
+// NewPairing creates a pairing from a PBC parameter string as returned by String.
+func NewPairing(params string) (*Pairing, error) {
+	if !strings.HasPrefix(params, "type ") {
+		return nil, errors.New("invalid pairing parameters")
+	}
+	p, err := pbc.NewPairingFromString(params)
+	if err != nil {
+		return nil, err
+	}
+
+	pairing := &Pairing{params: params, pairing: p}
+	for name, preset := range presets {
+		if params == preset {
+			pairing.name = name
+		}
+	}
+	return pairing, nil
+}
+
+// PresetPairing creates one of the fixed pairings TypeA and BNP256.
+func PresetPairing(name string) (*Pairing, error) {
+	params, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pairing: %s", name)
+	}
+	return NewPairing(params)
+}
+
+// GenerateTypeF creates fresh type F parameters, i.e. an asymmetric pairing on a
+// Barreto-Naehrig curve whose group order has the given number of bits. Only 256 and
+// 384 bits are supported, smaller curves fall short of 80 bits of security. Since the
+// generation is expensive, the parameters should be persisted with String and loaded
+// with NewPairing instead of generating them at each start.
+func GenerateTypeF(bits int) (*Pairing, error) {
+	switch bits {
+	case 256, 384:
+	default:
+		return nil, fmt.Errorf("unsupported type f group order size: %d", bits)
+	}
+	return NewPairing(pbc.GenerateF(uint32(bits)).String())
+}
+
+// String returns the PBC parameters of the pairing.
+func (p Pairing) String() string {
+	return p.params
+}
+
+// mustPairing panics if a pairing could not be created.
+func mustPairing(p *Pairing, err error) *Pairing {
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Option configures a HIBE instance.
+type Option func(*Pairing)
+
+// WithPairing sets the pairing of a HIBE instance. By default the fixed BNP256 pairing
+// is used.
+func WithPairing(pairing *Pairing) Option {
+	return func(p *Pairing) { *p = *pairing }
+}
+
+// newPairing applies the options to the default pairing.
+func newPairing(opts []Option) *Pairing {
+	p := *defaultPairing
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return &p
+}
+
+// id returns the identifier of the pairing stored in public parameters, i.e. the name of
+// a fixed pairing or the PBC parameters of a custom one.
+func (p Pairing) id() string {
+	if p.name != "" {
+		return p.name
+	}
+	return p.params
+}
+
+// pairingFromID creates the pairing designated by an identifier as returned by id.
+func pairingFromID(id string) (*Pairing, error) {
+	if _, ok := presets[id]; ok {
+		return PresetPairing(id)
+	}
+	return NewPairing(id)
+}
+
+// check verifies that public parameters have been created for the given pairing. Public
+// parameters that carry the full PBC parameters of a fixed pairing are accepted as well.
+func (p Pairing) check(id string) error {
+	if id != p.id() && id != p.params {
+		return errors.New("public parameters belong to a different pairing")
+	}
+	return nil
+}
 
 // HIBE specifies a general interface for HIBE constructions.
 type HIBE interface {
@@ -30,3 +184,9 @@ type HIBE interface {
 	// Decrypt deciphers a ciphertext pair with the secret key of an entity.
 	Decrypt(entity, c1, c2 []byte) ([]byte, error)
 }
+
+// Bounded is implemented by HIBE schemes whose hierarchies are limited to a fixed depth.
+type Bounded interface {
+	// MaxDepth returns the maximum number of levels an entity can be below the root.
+	MaxDepth() int
+}
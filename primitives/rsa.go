@@ -0,0 +1,22 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package primitives
+
+import "fmt"
+
+// Supported RSA modulus sizes in bits.
+const (
+	RSA2048 = 2048
+	RSA3072 = 3072
+	RSA4096 = 4096
+)
+
+// CheckRSASize returns an error if bits is not one of the supported RSA modulus sizes.
+func CheckRSASize(bits int) error {
+	switch bits {
+	case RSA2048, RSA3072, RSA4096:
+		return nil
+	}
+	return fmt.Errorf("unsupported rsa modulus size: %d", bits)
+}
@@ -0,0 +1,174 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/alecthomas/binary"
+)
+
+const (
+	abdallaNumPoints = 128 // default challenge length in bits.
+	abdallaMinPoints = 80  // minimum challenge length in bits.
+)
+
+// AbdallaReyzin implements the forward-secure digital signature scheme proposed by
+// Michel Abdalla and Leonid Reyzin in their 2000 paper A New Forward-Secure Digital
+// Signature Scheme. It is a variant of the Bellare-Miner scheme in which the points
+// are replaced by a single secret that is raised to the power of the whole challenge.
+// Keys consist of a single group element instead of one per challenge bit, at the
+// expense of an Update that performs one squaring per challenge bit.
+type AbdallaReyzin struct {
+	factoring
+}
+
+// abdallaPublicKey bundles the public key material.
+type abdallaPublicKey struct {
+	N, U []byte
+}
+
+// abdallaPrivateKey bundles the secret key material.
+type abdallaPrivateKey struct {
+	N, S []byte
+
+	J int // J specifies the current period of this private key.
+}
+
+// NewAbdallaReyzin creates a fresh Abdalla-Reyzin protocol instance. WithNumPoints sets
+// the challenge length in bits, which has to be at least 80. Without options a 512-bit
+// modulus, 128-bit challenges and 1000 periods are used.
+func NewAbdallaReyzin(opts ...Option) *AbdallaReyzin {
+	return &AbdallaReyzin{factoring: newFactoring(abdallaNumPoints, opts)}
+}
+
+// MaxPeriod returns the last period in which an Abdalla-Reyzin private key can be used.
+func (a AbdallaReyzin) MaxPeriod() int {
+	return a.maxPeriod
+}
+
+// Generate creates an Abdalla-Reyzin public/private key pair.
+func (a AbdallaReyzin) Generate() (pk, sk []byte, err error) {
+	if err := a.check(); err != nil {
+		return nil, nil, err
+	}
+
+	n, phi, err := a.modulus()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := unit(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := new(big.Int).Exp(s, a.exponent(a.points*(a.maxPeriod+1), phi), n)
+
+	pk, err = binary.Marshal(&abdallaPublicKey{N: n.Bytes(), U: u.Bytes()})
+	if err != nil {
+		return
+	}
+	sk, err = binary.Marshal(&abdallaPrivateKey{N: n.Bytes(), S: s.Bytes(), J: 0})
+	return
+}
+
+// Update evolves a private key into a new period.
+func (a AbdallaReyzin) Update(sk []byte) ([]byte, error) {
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+
+	var private abdallaPrivateKey
+	if err := binary.Unmarshal(sk, &private); err != nil {
+		return nil, err
+	}
+
+	if private.J >= a.maxPeriod {
+		return nil, errors.New("private key has surpassed max period")
+	}
+
+	n, s := new(big.Int).SetBytes(private.N), new(big.Int).SetBytes(private.S)
+	private.S = s.Exp(s, a.exponent(a.points, nil), n).Bytes()
+	private.J++
+
+	return binary.Marshal(&private)
+}
+
+// Sign creates an Abdalla-Reyzin signature of a given message.
+func (a AbdallaReyzin) Sign(sk, msg []byte) ([]byte, error) {
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+
+	var private abdallaPrivateKey
+	if err := binary.Unmarshal(sk, &private); err != nil {
+		return nil, err
+	}
+
+	n := new(big.Int).SetBytes(private.N)
+
+	R, err := unit(n)
+	if err != nil {
+		return nil, err
+	}
+	Y := new(big.Int).Exp(R, a.exponent(a.points*(a.maxPeriod+1-private.J), nil), n)
+
+	c := a.challenge(private.J, Y, msg)
+
+	Z := new(big.Int).Exp(new(big.Int).SetBytes(private.S), c, n)
+	Z.Mod(Z.Mul(Z, R), n)
+
+	return binary.Marshal(&bellareSignature{Y: Y.Bytes(), Z: Z.Bytes(), J: private.J})
+}
+
+// Verify checks the validity of a given signature.
+func (a AbdallaReyzin) Verify(pk, msg, sig []byte) error {
+	if err := a.check(); err != nil {
+		return err
+	}
+
+	var public abdallaPublicKey
+	if err := binary.Unmarshal(pk, &public); err != nil {
+		return err
+	}
+	var signature bellareSignature
+	if err := binary.Unmarshal(sig, &signature); err != nil {
+		return err
+	}
+
+	n := new(big.Int).SetBytes(public.N)
+	y, z, err := a.values(n, &signature)
+	if err != nil {
+		return err
+	}
+
+	c := a.challenge(signature.J, y, msg)
+
+	L := new(big.Int).Exp(z, a.exponent(a.points*(a.maxPeriod+1-signature.J), nil), n)
+
+	R := new(big.Int).Exp(new(big.Int).SetBytes(public.U), c, n)
+	R.Mod(R.Mul(R, y), n)
+
+	if L.Cmp(R) != 0 {
+		return errors.New("unable to verify signature")
+	}
+	return nil
+}
+
+// check verifies that the parameters are supported and that the challenges are long
+// enough to prevent forgeries.
+func (a AbdallaReyzin) check() error {
+	if a.points < abdallaMinPoints {
+		return fmt.Errorf("challenge length below %d bits: %d", abdallaMinPoints, a.points)
+	}
+	return a.factoring.check()
+}
+
+// challenge truncates the hashed period, commitment and message to the challenge length.
+func (a AbdallaReyzin) challenge(j int, y *big.Int, msg []byte) *big.Int {
+	c := challenge(j, y, msg)
+	return c.Mod(c, a.exponent(a.points, nil))
+}
@@ -0,0 +1,54 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbdallaReyzin(t *testing.T) {
+	require := require.New(t)
+
+	for _, a := range []*AbdallaReyzin{
+		NewAbdallaReyzin(WithMaxPeriod(20)),
+		NewAbdallaReyzin(WithSecurity(1024), WithNumPoints(128), WithMaxPeriod(5)),
+	} {
+		pk, sk, err := a.Generate()
+		require.Nil(err)
+
+		msg := []byte("abdalla")
+
+		var sigs [][]byte
+		for i := 0; i <= a.MaxPeriod(); i++ {
+			sig, err := a.Sign(sk, msg)
+			require.Nil(err)
+			require.Nil(a.Verify(pk, msg, sig))
+			require.NotNil(a.Verify(pk, []byte("abc"), sig))
+			sigs = append(sigs, sig)
+
+			if i < a.MaxPeriod() {
+				sk, err = a.Update(sk)
+				require.Nil(err)
+			}
+		}
+
+		_, err = a.Update(sk)
+		require.NotNil(err)
+
+		for _, sig := range sigs {
+			require.Nil(a.Verify(pk, msg, sig))
+		}
+	}
+
+	_, _, err := NewAbdallaReyzin(WithSecurity(4096)).Generate()
+	require.NotNil(err)
+
+	// Short challenges are rejected since forgeries succeed with probability 2^-points.
+	weak := NewAbdallaReyzin(WithNumPoints(64))
+	_, _, err = weak.Generate()
+	require.NotNil(err)
+	require.NotNil(weak.Verify(nil, nil, nil))
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"crypto/sha512"
 	"errors"
+	"fmt"
 	"math/big"
 	"strconv"
 
@@ -16,27 +17,57 @@ import (
 )
 
 const (
-	bellareSecurity  = 512  // security parameter in bits.
-	bellareNumPoints = 10   // number of points in the keys.
-	bellareMaxPeriod = 1000 // maximum value of allowed key evolutions.
+	bellareSecurity  = 512  // default security parameter in bits.
+	bellareNumPoints = 10   // default number of points in the keys.
+	bellareMaxPeriod = 1000 // default maximum value of allowed key evolutions.
 )
 
+// Option configures the parameters of the factoring-based forward-secure signature
+// schemes Bellare and AbdallaReyzin.
+type Option func(*factoring)
+
+// WithSecurity sets the size of the Blum integer modulus in bits. Only the values 512,
+// 1024, 2048 and 3072 are supported, all operations fail for any other value. The
+// default of 512 bits is only suitable for testing.
+func WithSecurity(bits int) Option {
+	return func(f *factoring) { f.security = bits }
+}
+
+// WithNumPoints sets the number of points of a Bellare key or the challenge length in
+// bits of an AbdallaReyzin key. In both schemes it corresponds to the number of digest
+// bits signed by the key and can be at most 512. A signature verifies for a different
+// message with probability 2^-points, hence the value bounds the soundness of both
+// schemes. AbdallaReyzin rejects challenges shorter than 80 bits.
+func WithNumPoints(points int) Option {
+	return func(f *factoring) { f.points = points }
+}
+
+// WithMaxPeriod sets the last period in which a private key can be used.
+func WithMaxPeriod(period int) Option {
+	return func(f *factoring) { f.maxPeriod = period }
+}
+
+// factoring bundles the parameters of the factoring-based schemes.
+type factoring struct {
+	security, points, maxPeriod int
+}
+
 // Bellare implements the forward-secure digital signature schemes proposed
 // by Mihir Bellare and Sara Miner in their 1999 paper A Forward-Secure Digital
 // Signature Scheme.
-type Bellare struct{}
+type Bellare struct {
+	factoring
+}
 
 // bellarePublicKey bundles the public key material.
 type bellarePublicKey struct {
 	N []byte
-	//U [bellareNumPoints][]byte
 	U [][]byte
 }
 
 // bellarePrivateKey bundles the secret key material.
 type bellarePrivateKey struct {
 	N []byte
-	//S [bellareNumPoints][]byte
 	S [][]byte
 
 	J int // J specifies the current period of this private key.
@@ -48,48 +79,49 @@ type bellareSignature struct {
 	J    int
 }
 
-// NewBellare creates a fresh Bellare protocol instance.
-func NewBellare() *Bellare {
-	return &Bellare{}
+// NewBellare creates a fresh Bellare protocol instance. Without options a 512-bit
+// modulus, 10 points and 1000 periods are used. With 10 points a signature can be
+// forged for a different message with probability 2^-10, use WithNumPoints to sign
+// more digest bits.
+func NewBellare(opts ...Option) *Bellare {
+	return &Bellare{factoring: newFactoring(bellareNumPoints, opts)}
+}
+
+// MaxPeriod returns the last period in which a Bellare private key can be used.
+func (b Bellare) MaxPeriod() int {
+	return b.maxPeriod
 }
 
 // Generate creates a Bellare public/private key pair.
 func (b Bellare) Generate() (pk, sk []byte, err error) {
-	var p *big.Int
-	var q *big.Int
-	for {
-		p, _ = rand.Prime(rand.Reader, bellareSecurity/2)
-		q, _ = rand.Prime(rand.Reader, bellareSecurity/2)
+	if err := b.check(); err != nil {
+		return nil, nil, err
+	}
 
-		rp := new(big.Int).Mod(p, big.NewInt(4))
-		rq := new(big.Int).Mod(q, big.NewInt(4))
-		if rp.Uint64() == 3 && rq.Uint64() == 3 {
-			break
-		}
+	n, phi, err := b.modulus()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	N := new(big.Int).Mul(p, q)
+	// The public points are computed with the exponent reduced modulo phi(N) instead of
+	// squaring each secret point maxPeriod+1 times.
+	e := b.exponent(b.maxPeriod+1, phi)
 
-	S := make([][]byte, bellareNumPoints)
-	U := make([][]byte, bellareNumPoints)
-	for i := 0; i < bellareNumPoints; i++ {
-		var s *big.Int
-		for {
-			s, _ = rand.Int(rand.Reader, N)
-			if s.Uint64() != 0 {
-				break
-			}
+	S := make([][]byte, b.points)
+	U := make([][]byte, b.points)
+	for i := range S {
+		s, err := unit(n)
+		if err != nil {
+			return nil, nil, err
 		}
-		e := new(big.Int).Exp(big.NewInt(2), big.NewInt(bellareMaxPeriod+1), nil)
-		u := new(big.Int).Exp(s, e, N)
-		S[i], U[i] = s.Bytes(), u.Bytes()
+		S[i], U[i] = s.Bytes(), new(big.Int).Exp(s, e, n).Bytes()
 	}
 
-	pk, err = binary.Marshal(&bellarePublicKey{N: N.Bytes(), U: U})
+	pk, err = binary.Marshal(&bellarePublicKey{N: n.Bytes(), U: U})
 	if err != nil {
 		return
 	}
-	sk, err = binary.Marshal(&bellarePrivateKey{N: N.Bytes(), S: S, J: 0})
+	sk, err = binary.Marshal(&bellarePrivateKey{N: n.Bytes(), S: S, J: 0})
 	return
 }
 
@@ -100,18 +132,23 @@ func (b Bellare) Update(sk []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	if private.J > bellareMaxPeriod {
+	if private.J >= b.maxPeriod {
 		return nil, errors.New("private key has surpassed max period")
 	}
+	if len(private.S) != b.points {
+		return nil, errors.New("invalid bellare private key")
+	}
 
 	n := new(big.Int).SetBytes(private.N)
 
-	S := make([][]byte, bellareNumPoints)
-	for i := 0; i < bellareNumPoints; i++ {
-		s := new(big.Int).SetBytes(private.S[i])
-		S[i] = new(big.Int).Exp(s, big.NewInt(2), n).Bytes()
+	s := new(big.Int)
+	for i := range private.S {
+		s.SetBytes(private.S[i])
+		private.S[i] = s.Mod(s.Mul(s, s), n).Bytes()
 	}
-	return binary.Marshal(&bellarePrivateKey{N: private.N, S: S, J: private.J + 1})
+	private.J++
+
+	return binary.Marshal(&private)
 }
 
 // Sign creates a Bellare signature of a given message.
@@ -120,31 +157,26 @@ func (b Bellare) Sign(sk, msg []byte) ([]byte, error) {
 	if err := binary.Unmarshal(sk, &private); err != nil {
 		return nil, err
 	}
+	if len(private.S) != b.points {
+		return nil, errors.New("invalid bellare private key")
+	}
 
 	n := new(big.Int).SetBytes(private.N)
 
-	var R *big.Int
-	for {
-		R, _ = rand.Int(rand.Reader, n)
-		if R.Uint64() != 0 {
-			break
-		}
+	R, err := unit(n)
+	if err != nil {
+		return nil, err
 	}
-	e := new(big.Int).Exp(big.NewInt(2), big.NewInt(bellareMaxPeriod+1-int64(private.J)), nil)
-	Y := new(big.Int).Exp(R, e, n)
+	Y := new(big.Int).Exp(R, b.exponent(b.maxPeriod+1-private.J, nil), n)
 
-	digest := primitives.Digest(sha512.New(), []byte(strconv.Itoa(private.J)), Y.Bytes(), msg)
-	c := new(big.Int).SetBytes(digest)
+	c := challenge(private.J, Y, msg)
 
-	P := big.NewInt(1)
-	for i := 0; i < bellareNumPoints; i++ {
-		e := new(big.Int).And(new(big.Int).Rsh(c, uint(i)), big.NewInt(1))
-
-		s := new(big.Int).SetBytes(private.S[i])
-		P = new(big.Int).Mul(P, new(big.Int).Exp(s, e, nil))
+	Z := R
+	for i := range private.S {
+		if c.Bit(i) == 1 {
+			Z.Mod(Z.Mul(Z, new(big.Int).SetBytes(private.S[i])), n)
+		}
 	}
-	P = new(big.Int).Mul(R, P)
-	Z := new(big.Int).Mod(P, n)
 
 	return binary.Marshal(&bellareSignature{Y: Y.Bytes(), Z: Z.Bytes(), J: private.J})
 }
@@ -159,28 +191,124 @@ func (b Bellare) Verify(pk, msg, sig []byte) error {
 	if err := binary.Unmarshal(sig, &signature); err != nil {
 		return err
 	}
+	if len(public.U) != b.points {
+		return errors.New("invalid bellare public key")
+	}
 
-	y, z := new(big.Int).SetBytes(signature.Y), new(big.Int).SetBytes(signature.Z)
 	n := new(big.Int).SetBytes(public.N)
+	y, z, err := b.values(n, &signature)
+	if err != nil {
+		return err
+	}
 
-	digest := primitives.Digest(sha512.New(), []byte(strconv.Itoa(signature.J)), y.Bytes(), msg)
-	c := new(big.Int).SetBytes(digest)
-
-	e := new(big.Int).Exp(big.NewInt(2), big.NewInt(bellareMaxPeriod+1-int64(signature.J)), nil)
-	L := new(big.Int).Exp(z, e, n)
+	c := challenge(signature.J, y, msg)
 
-	P := big.NewInt(1)
-	for i := 0; i < bellareNumPoints; i++ {
-		e := new(big.Int).And(new(big.Int).Rsh(c, uint(i)), big.NewInt(1))
+	L := new(big.Int).Exp(z, b.exponent(b.maxPeriod+1-signature.J, nil), n)
 
-		u := new(big.Int).SetBytes(public.U[i])
-		P = new(big.Int).Mul(P, new(big.Int).Exp(u, e, nil))
+	R := y
+	for i := range public.U {
+		if c.Bit(i) == 1 {
+			R.Mod(R.Mul(R, new(big.Int).SetBytes(public.U[i])), n)
+		}
 	}
-	P = new(big.Int).Mul(y, P)
-	R := new(big.Int).Mod(P, n)
 
 	if L.Cmp(R) != 0 {
 		return errors.New("unable to verify signature")
 	}
 	return nil
 }
+
+// newFactoring applies the options to the default parameters with a given default number
+// of points.
+func newFactoring(points int, opts []Option) factoring {
+	f := factoring{security: bellareSecurity, points: points, maxPeriod: bellareMaxPeriod}
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return f
+}
+
+// check verifies that the parameters are supported.
+func (f factoring) check() error {
+	switch f.security {
+	case 512, 1024, 2048, 3072:
+	default:
+		return fmt.Errorf("unsupported security parameter: %d", f.security)
+	}
+	if f.points < 1 || f.points > 8*sha512.Size {
+		return fmt.Errorf("unsupported number of points: %d", f.points)
+	}
+	if f.maxPeriod < 1 {
+		return fmt.Errorf("unsupported max period: %d", f.maxPeriod)
+	}
+	return nil
+}
+
+// modulus creates a Blum integer N of the configured size and returns it together
+// with phi(N).
+func (f factoring) modulus() (n, phi *big.Int, err error) {
+	one := big.NewInt(1)
+
+	var p, q *big.Int
+	for {
+		if p, err = rand.Prime(rand.Reader, f.security/2); err != nil {
+			return
+		}
+		if q, err = rand.Prime(rand.Reader, f.security/2); err != nil {
+			return
+		}
+		if p.Bit(1) == 1 && q.Bit(1) == 1 && p.Cmp(q) != 0 {
+			break
+		}
+	}
+
+	n = new(big.Int).Mul(p, q)
+	phi = new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+	return
+}
+
+// exponent returns 2^k, reduced modulo phi if it is given.
+func (f factoring) exponent(k int, phi *big.Int) *big.Int {
+	if phi == nil {
+		return new(big.Int).Lsh(big.NewInt(1), uint(k))
+	}
+	return new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(k)), phi)
+}
+
+// values decodes the commitment and response of a signature and checks that both are
+// units and the period is valid.
+func (f factoring) values(n *big.Int, signature *bellareSignature) (y, z *big.Int, err error) {
+	if signature.J < 0 || signature.J > f.maxPeriod {
+		return nil, nil, errors.New("invalid signature period")
+	}
+
+	one := big.NewInt(1)
+
+	y, z = new(big.Int).SetBytes(signature.Y), new(big.Int).SetBytes(signature.Z)
+	for _, v := range []*big.Int{y, z} {
+		if v.Cmp(n) >= 0 || new(big.Int).GCD(nil, nil, v, n).Cmp(one) != 0 {
+			return nil, nil, errors.New("invalid signature")
+		}
+	}
+	return
+}
+
+// challenge hashes the period, the commitment and a message.
+func challenge(j int, y *big.Int, msg []byte) *big.Int {
+	digest := primitives.Digest(sha512.New(), []byte(strconv.Itoa(j)), y.Bytes(), msg)
+	return new(big.Int).SetBytes(digest)
+}
+
+// unit samples a random element of the multiplicative group modulo n.
+func unit(n *big.Int) (*big.Int, error) {
+	one := big.NewInt(1)
+	for {
+		u, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if u.Sign() != 0 && new(big.Int).GCD(nil, nil, u, n).Cmp(one) == 0 {
+			return u, nil
+		}
+	}
+}
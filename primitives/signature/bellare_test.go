@@ -6,6 +6,7 @@ package signature
 import (
 	"testing"
 
+	"github.com/alecthomas/binary"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,3 +28,103 @@ func TestBellare(t *testing.T) {
 		require.Nil(err)
 	}
 }
+
+func TestBellare_Params(t *testing.T) {
+	require := require.New(t)
+
+	b := NewBellare(WithSecurity(1024), WithNumPoints(64), WithMaxPeriod(5))
+	require.Equal(5, b.MaxPeriod())
+
+	pk, sk, err := b.Generate()
+	require.Nil(err)
+
+	msg := []byte("bellare")
+
+	for i := 0; i <= b.MaxPeriod(); i++ {
+		sig, err := b.Sign(sk, msg)
+		require.Nil(err)
+		require.Nil(b.Verify(pk, msg, sig))
+		require.NotNil(b.Verify(pk, []byte("abc"), sig))
+
+		if i < b.MaxPeriod() {
+			sk, err = b.Update(sk)
+			require.Nil(err)
+		}
+	}
+
+	_, err = b.Update(sk)
+	require.NotNil(err)
+
+	// Signatures with a zero commitment and response must not verify.
+	sig, err := binary.Marshal(&bellareSignature{Y: []byte{0}, Z: []byte{0}, J: 0})
+	require.Nil(err)
+	require.NotNil(b.Verify(pk, msg, sig))
+
+	for _, opt := range []Option{WithSecurity(768), WithNumPoints(0), WithNumPoints(513), WithMaxPeriod(0)} {
+		_, _, err := NewBellare(opt).Generate()
+		require.NotNil(err)
+	}
+}
+
+// params lists the parameter sets of the factoring-based scheme benchmarks.
+var params = []struct {
+	name string
+	opts []Option
+}{
+	{"512/10/1000", nil},
+	{"1024/64/100", []Option{WithSecurity(1024), WithNumPoints(64), WithMaxPeriod(100)}},
+	{"2048/128/100", []Option{WithSecurity(2048), WithNumPoints(128), WithMaxPeriod(100)}},
+}
+
+func BenchmarkBellare(b *testing.B) {
+	for _, p := range params {
+		benchmarkForward(b, p.name, NewBellare(p.opts...))
+	}
+}
+
+func BenchmarkAbdallaReyzin(b *testing.B) {
+	for _, p := range []struct {
+		name string
+		opts []Option
+	}{
+		{"512/128/1000", nil},
+		{"1024/128/100", []Option{WithSecurity(1024), WithMaxPeriod(100)}},
+		{"2048/256/100", []Option{WithSecurity(2048), WithNumPoints(256), WithMaxPeriod(100)}},
+	} {
+		benchmarkForward(b, p.name, NewAbdallaReyzin(p.opts...))
+	}
+}
+
+// benchmarkForward benchmarks key generation, Update and Sign of a forward-secure scheme.
+func benchmarkForward(b *testing.B, name string, f ForwardSignature) {
+	msg := []byte("bench")
+
+	b.Run(name+"/Generate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := f.Generate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	_, sk, err := f.Generate()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run(name+"/Update", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := f.Update(sk); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run(name+"/Sign", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := f.Sign(sk, msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
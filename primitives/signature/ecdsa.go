@@ -10,6 +10,8 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"errors"
+	"fmt"
+	"io"
 	"math/big"
 
 	"github.com/qantik/ratcheted/primitives"
@@ -31,16 +33,25 @@ func (e ECDSA) Generate() (pk, sk []byte, err error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	return e.marshal(secret)
+}
 
-	sk, err = x509.MarshalECPrivateKey(secret)
-	if err != nil {
-		return nil, nil, err
-	}
-	pk, err = x509.MarshalPKIXPublicKey(&secret.PublicKey)
-	if err != nil {
+// generate derives an ECDSA public/private key pair from a seed.
+func (e ECDSA) generate(seed []byte) (pk, sk []byte, err error) {
+	params := e.curve.Params()
+	b := make([]byte, params.BitSize/8+8)
+	if _, err := io.ReadFull(primitives.NewDRBG(seed, "ecdsa"), b); err != nil {
 		return nil, nil, err
 	}
-	return
+
+	one := big.NewInt(1)
+	d := new(big.Int).SetBytes(b)
+	d.Mod(d, new(big.Int).Sub(params.N, one))
+	d.Add(d, one)
+
+	secret := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: e.curve}, D: d}
+	secret.X, secret.Y = e.curve.ScalarBaseMult(d.Bytes())
+	return e.marshal(secret)
 }
 
 // Sign a message with a ECDSA private key.
@@ -55,13 +66,12 @@ func (e ECDSA) Sign(sk, msg []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	sig := append(r.Bytes(), s.Bytes()...)
-
-	// FIXME: Every once in a while a signature with bogus length is created that cannot
-	// be verified. In that case retry a new one.
-	if len(sig) != e.signatureSize() {
-		return e.Sign(sk, msg)
-	}
+	// Both integers are encoded with the fixed width of the curve order such that the
+	// signature always has the same size.
+	size := e.signatureSize() / 2
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
 	return sig, nil
 }
 
@@ -71,16 +81,42 @@ func (e ECDSA) Verify(pk, msg, sig []byte) error {
 	if err != nil {
 		return err
 	}
+	key, ok := public.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("invalid ecdsa public key")
+	}
+	if len(sig) != e.signatureSize() {
+		return fmt.Errorf("invalid signature size: %d", len(sig))
+	}
 
 	r := new(big.Int).SetBytes(sig[:e.signatureSize()/2])
 	s := new(big.Int).SetBytes(sig[e.signatureSize()/2:])
-	if !ecdsa.Verify(public.(*ecdsa.PublicKey), primitives.Digest(sha256.New(), msg), r, s) {
+	if !ecdsa.Verify(key, primitives.Digest(sha256.New(), msg), r, s) {
 		return errors.New("unable to verify signature")
 	}
 	return nil
 }
 
-// signature size returns the size of a ECDSA signature based on the used curve in bytes.
+// VerifyBatch checks the validity of a batch of ECDSA signatures. Since ECDSA has no
+// batch verification equation, the signatures are verified one after the other.
+func (e ECDSA) VerifyBatch(pks, msgs, sigs [][]byte) error {
+	return verifyEach(e.Verify, pks, msgs, sigs)
+}
+
+// marshal encodes the public and private key of an ECDSA key pair.
+func (e ECDSA) marshal(secret *ecdsa.PrivateKey) (pk, sk []byte, err error) {
+	sk, err = x509.MarshalECPrivateKey(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err = x509.MarshalPKIXPublicKey(&secret.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// signatureSize returns the size of a ECDSA signature based on the used curve in bytes.
 func (e ECDSA) signatureSize() int {
-	return 2 * e.curve.Params().BitSize / 8
+	return 2 * ((e.curve.Params().N.BitLen() + 7) / 8)
 }
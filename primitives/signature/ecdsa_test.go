@@ -26,3 +26,37 @@ func TestECDSA(t *testing.T) {
 	require.Nil(ecdsa.Verify(pk, msg, sig))
 	require.NotNil(ecdsa.Verify(pk, []byte("abc"), sig))
 }
+
+func TestECDSA_Size(t *testing.T) {
+	require := require.New(t)
+
+	for _, c := range []struct {
+		curve elliptic.Curve
+		size  int
+	}{{elliptic.P256(), 64}, {elliptic.P384(), 96}, {elliptic.P521(), 132}} {
+		ecdsa := NewECDSA(c.curve)
+
+		pk, sk, err := ecdsa.Generate()
+		require.Nil(err)
+
+		// Roughly one in 128 signatures has an integer with a leading zero byte.
+		for i := 0; i < 300; i++ {
+			msg := []byte{byte(i), byte(i >> 8)}
+
+			sig, err := ecdsa.Sign(sk, msg)
+			require.Nil(err)
+			require.Equal(c.size, len(sig))
+			require.Nil(ecdsa.Verify(pk, msg, sig))
+		}
+
+		msg := []byte("ecdsa")
+
+		sig, err := ecdsa.Sign(sk, msg)
+		require.Nil(err)
+		require.NotNil(ecdsa.Verify(pk, msg, sig[1:]))
+	}
+}
+
+func TestECDSA_Batch(t *testing.T) {
+	testBatch(t, NewECDSA(elliptic.P256()))
+}
@@ -0,0 +1,141 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/edwards25519"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// ed25519BatchSize is the smallest number of signatures that is checked with the batch
+// verification equation, see BenchmarkEd25519_VerifyBatch.
+const ed25519BatchSize = 2
+
+// Ed25519 designates the Ed25519 scheme handler object. Private keys are stored as
+// 32-byte seeds from which the signing key is expanded on demand.
+type Ed25519 struct{}
+
+// NewEd25519 creates a fresh Ed25519 instance.
+func NewEd25519() *Ed25519 {
+	return &Ed25519{}
+}
+
+// Generate a fresh Ed25519 public/private key pair.
+func (e Ed25519) Generate() (pk, sk []byte, err error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return public, private.Seed(), nil
+}
+
+// generate derives an Ed25519 public/private key pair from a seed.
+func (e Ed25519) generate(seed []byte) (pk, sk []byte, err error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, nil, fmt.Errorf("invalid seed size: %d", len(seed))
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+	return private.Public().(ed25519.PublicKey), private.Seed(), nil
+}
+
+// Sign a message with an Ed25519 private key.
+func (e Ed25519) Sign(sk, msg []byte) ([]byte, error) {
+	if len(sk) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key size: %d", len(sk))
+	}
+	return ed25519.Sign(ed25519.NewKeyFromSeed(sk), msg), nil
+}
+
+// Verify checks the validity of an Ed25519 signature.
+func (e Ed25519) Verify(pk, msg, sig []byte) error {
+	if len(pk) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key size: %d", len(pk))
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pk), msg, sig) {
+		return errors.New("unable to verify signature")
+	}
+	return nil
+}
+
+// VerifyBatch checks the validity of a batch of Ed25519 signatures with the randomised
+// batch verification equation
+//
+//	[8]([-sum z_i S_i]B + sum [z_i]R_i + sum [z_i k_i]A_i) = 0
+//
+// for random 128-bit scalars z_i, which takes a single multi-scalar multiplication. If the
+// equation does not hold, the signatures are verified one after the other to report the
+// first invalid one. Batches below ed25519BatchSize are always verified one by one.
+//
+// Unlike Verify, the equation is cofactored: signatures whose R or public key carries a
+// small-order component may be accepted in a batch although Verify rejects them. Honestly
+// generated keys and signatures are accepted or rejected by both alike.
+func (e Ed25519) VerifyBatch(pks, msgs, sigs [][]byte) error {
+	n := len(sigs)
+	if len(pks) != n || len(msgs) != n {
+		return errors.New("batch sizes do not match")
+	}
+	if n >= ed25519BatchSize && e.batch(pks, msgs, sigs) {
+		return nil
+	}
+	return verifyEach(e.Verify, pks, msgs, sigs)
+}
+
+// batch evaluates the batch verification equation. It returns false if the equation does
+// not hold or any public key or signature cannot be decoded.
+func (e Ed25519) batch(pks, msgs, sigs [][]byte) bool {
+	scalars := make([]*edwards25519.Scalar, 0, 2*len(sigs)+1)
+	points := make([]*edwards25519.Point, 0, 2*len(sigs)+1)
+
+	s := edwards25519.NewScalar()
+	z := make([]byte, 32)
+	for i := range sigs {
+		if len(pks[i]) != ed25519.PublicKeySize || len(sigs[i]) != ed25519.SignatureSize {
+			return false
+		}
+		A, err := new(edwards25519.Point).SetBytes(pks[i])
+		if err != nil {
+			return false
+		}
+		// Verify recomputes R and compares its encoding, hence only canonical ones pass.
+		R, err := new(edwards25519.Point).SetBytes(sigs[i][:32])
+		if err != nil || !bytes.Equal(R.Bytes(), sigs[i][:32]) {
+			return false
+		}
+		S, err := edwards25519.NewScalar().SetCanonicalBytes(sigs[i][32:])
+		if err != nil {
+			return false
+		}
+		k, err := edwards25519.NewScalar().SetUniformBytes(
+			primitives.Digest(sha512.New(), sigs[i][:32], pks[i], msgs[i]))
+		if err != nil {
+			return false
+		}
+
+		if _, err := io.ReadFull(rand.Reader, z[:16]); err != nil {
+			return false
+		}
+		zi, err := edwards25519.NewScalar().SetCanonicalBytes(z)
+		if err != nil {
+			return false
+		}
+
+		s.MultiplyAdd(zi, S, s)
+		scalars = append(scalars, zi, edwards25519.NewScalar().Multiply(zi, k))
+		points = append(points, R, A)
+	}
+	scalars = append(scalars, s.Negate(s))
+	points = append(points, edwards25519.NewGeneratorPoint())
+
+	check := new(edwards25519.Point).VarTimeMultiScalarMult(scalars, points)
+	return check.MultByCofactor(check).Equal(edwards25519.NewIdentityPoint()) == 1
+}
@@ -0,0 +1,158 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEd25519(t *testing.T) {
+	require := require.New(t)
+
+	ed := NewEd25519()
+
+	pk, sk, err := ed.Generate()
+	require.Nil(err)
+
+	msg := []byte("ed25519")
+
+	sig, err := ed.Sign(sk, msg)
+	require.Nil(err)
+
+	require.Nil(ed.Verify(pk, msg, sig))
+	require.NotNil(ed.Verify(pk, []byte("abc"), sig))
+	require.NotNil(ed.Verify(pk[1:], msg, sig))
+}
+
+// TestEd25519_Vector checks the first test vector of RFC 8032.
+func TestEd25519_Vector(t *testing.T) {
+	require := require.New(t)
+
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		require.Nil(err)
+		return b
+	}
+
+	sk := decode("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	pk := decode("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	expected := decode("e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e06522490155" +
+		"5fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+
+	sig, err := NewEd25519().Sign(sk, nil)
+	require.Nil(err)
+	require.True(bytes.Equal(expected, sig))
+	require.Nil(NewEd25519().Verify(pk, nil, sig))
+}
+
+func TestEd25519_Batch(t *testing.T) {
+	testBatch(t, NewEd25519())
+}
+
+// testBatch checks the batch verification of a signature scheme against the individual
+// verification of each signature.
+func testBatch(t *testing.T, s Signature) {
+	require := require.New(t)
+
+	b, ok := s.(BatchVerifier)
+	require.True(ok)
+
+	var pks, msgs, sigs [][]byte
+	for i := 0; i < 10; i++ {
+		pk, sk, err := s.Generate()
+		require.Nil(err)
+
+		msg := []byte{byte(i)}
+		sig, err := s.Sign(sk, msg)
+		require.Nil(err)
+
+		pks, msgs, sigs = append(pks, pk), append(msgs, msg), append(sigs, sig)
+	}
+	require.Nil(b.VerifyBatch(pks, msgs, sigs))
+	require.Nil(b.VerifyBatch(pks[:1], msgs[:1], sigs[:1]))
+	require.Nil(b.VerifyBatch(nil, nil, nil))
+	require.NotNil(b.VerifyBatch(pks[:9], msgs, sigs))
+
+	// A single invalid signature fails the batch and is reported.
+	msgs[7] = []byte("abc")
+	err := b.VerifyBatch(pks, msgs, sigs)
+	require.NotNil(err)
+	require.Contains(err.Error(), "signature 7")
+	msgs[7] = []byte{7}
+
+	sigs[3], sigs[4] = sigs[4], sigs[3]
+	require.NotNil(b.VerifyBatch(pks, msgs, sigs))
+	require.NotNil(b.VerifyBatch(pks[3:5], msgs[3:5], sigs[3:5]))
+}
+
+func TestEd25519_BatchEncoding(t *testing.T) {
+	require := require.New(t)
+
+	e := NewEd25519()
+
+	var pks, msgs, sigs [][]byte
+	for i := 0; i < 4; i++ {
+		pk, sk, err := e.Generate()
+		require.Nil(err)
+		sig, err := e.Sign(sk, []byte{byte(i)})
+		require.Nil(err)
+		pks, msgs, sigs = append(pks, pk), append(msgs, []byte{byte(i)}), append(sigs, sig)
+	}
+	require.Nil(e.VerifyBatch(pks, msgs, sigs))
+
+	// A non-canonical S, obtained by adding the group order, is rejected as by Verify.
+	order, _ := hex.DecodeString("edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010")
+	s := append([]byte{}, sigs[2][32:]...)
+	var carry uint16
+	for i := range s {
+		v := uint16(s[i]) + uint16(order[i]) + carry
+		s[i], carry = byte(v), v>>8
+	}
+	sigs[2] = append(append([]byte{}, sigs[2][:32]...), s...)
+	require.NotNil(e.Verify(pks[2], msgs[2], sigs[2]))
+	require.NotNil(e.VerifyBatch(pks, msgs, sigs))
+
+	sigs[1] = sigs[1][:63]
+	require.NotNil(e.VerifyBatch(pks, msgs, sigs))
+}
+
+func BenchmarkEd25519_VerifyBatch(b *testing.B) {
+	e := NewEd25519()
+	msg := []byte("bench")
+
+	for _, n := range []int{1, 2, 4, 16, 64} {
+		pks, msgs, sigs := make([][]byte, n), make([][]byte, n), make([][]byte, n)
+		for i := range sigs {
+			pk, sk, err := e.Generate()
+			if err != nil {
+				b.Fatal(err)
+			}
+			sig, err := e.Sign(sk, msg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			pks[i], msgs[i], sigs[i] = pk, msg, sig
+		}
+
+		b.Run(fmt.Sprintf("%d/Verify", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := verifyEach(e.Verify, pks, msgs, sigs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("%d/Batch", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if !e.batch(pks, msgs, sigs) {
+					b.Fatal("batch verification failed")
+				}
+			}
+		})
+	}
+}
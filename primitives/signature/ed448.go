@@ -0,0 +1,61 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/circl/sign/ed448"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// Ed448 designates the Ed448 scheme handler object. Private keys are stored as 57-byte
+// seeds from which the signing key is expanded on demand. Signatures are created without
+// a context string.
+type Ed448 struct{}
+
+// NewEd448 creates a fresh Ed448 instance.
+func NewEd448() *Ed448 {
+	return &Ed448{}
+}
+
+// Generate a fresh Ed448 public/private key pair.
+func (e Ed448) Generate() (pk, sk []byte, err error) {
+	public, private, err := ed448.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return public, private.Seed(), nil
+}
+
+// generate derives an Ed448 public/private key pair from a seed.
+func (e Ed448) generate(seed []byte) (pk, sk []byte, err error) {
+	public, private, err := ed448.GenerateKey(primitives.NewDRBG(seed, "ed448"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return public, private.Seed(), nil
+}
+
+// Sign a message with an Ed448 private key.
+func (e Ed448) Sign(sk, msg []byte) ([]byte, error) {
+	if len(sk) != ed448.SeedSize {
+		return nil, fmt.Errorf("invalid private key size: %d", len(sk))
+	}
+	return ed448.Sign(ed448.NewKeyFromSeed(sk), msg, ""), nil
+}
+
+// Verify checks the validity of an Ed448 signature.
+func (e Ed448) Verify(pk, msg, sig []byte) error {
+	if len(pk) != ed448.PublicKeySize {
+		return fmt.Errorf("invalid public key size: %d", len(pk))
+	}
+	if !ed448.Verify(ed448.PublicKey(pk), msg, sig, "") {
+		return errors.New("unable to verify signature")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEd448(t *testing.T) {
+	require := require.New(t)
+
+	ed := NewEd448()
+
+	pk, sk, err := ed.Generate()
+	require.Nil(err)
+
+	msg := []byte("ed448")
+
+	sig, err := ed.Sign(sk, msg)
+	require.Nil(err)
+
+	require.Nil(ed.Verify(pk, msg, sig))
+	require.NotNil(ed.Verify(pk, []byte("abc"), sig))
+	require.NotNil(ed.Verify(pk[1:], msg, sig))
+
+	_, err = ed.Sign(sk[1:], msg)
+	require.NotNil(err)
+}
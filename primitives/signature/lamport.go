@@ -4,38 +4,106 @@
 package signature
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash"
 	"io"
 
-	"github.com/dchest/wots"
+	"github.com/qantik/ratcheted/primitives"
 )
 
-// Lamport is the Lamport one-time signature handler object.
+// Lamport is the Lamport one-time signature handler object. A private key consists of a
+// seed from which two secret values per bit of the message digest are derived, the public
+// key holds the hashes of all these values. A private key can only be used once, Sign
+// erases it in place and refuses any further signature with ErrKeyUsed.
+//
+// Only the given slice is erased. Copies of the private key, such as a persisted state
+// restored from before the signature, are not and still sign, hence a caller has to
+// persist the erased key or its replacement before it releases a signature.
 type Lamport struct {
-	scheme *wots.Scheme
+	rand io.Reader
+	hash func() hash.Hash
 }
 
 // NewLamport creates a fresh Lamport instance for a given hash function.
 func NewLamport(rand io.Reader, hash func() hash.Hash) *Lamport {
-	return &Lamport{scheme: wots.NewScheme(hash, rand)}
+	return &Lamport{rand: rand, hash: hash}
 }
 
 // Generate creates a new public/secret key pair.
 func (l Lamport) Generate() (pk, sk []byte, err error) {
-	sk, pk, err = l.scheme.GenerateKeyPair()
-	return
+	n := l.hash().Size()
+
+	seed := make([]byte, n)
+	if _, err := io.ReadFull(l.rand, seed); err != nil {
+		return nil, nil, err
+	}
+
+	for i := 0; i < 8*n; i++ {
+		for b := 0; b < 2; b++ {
+			pk = append(pk, primitives.Digest(l.hash(), l.secret(seed, i, b))...)
+		}
+	}
+	return pk, append([]byte{0}, seed...), nil
+}
+
+// generate derives a public/secret key pair from a seed.
+func (l Lamport) generate(seed []byte) (pk, sk []byte, err error) {
+	l.rand = primitives.NewDRBG(seed, "lamport")
+	return l.Generate()
 }
 
 // Sign creates a signature of a given message using a secret key.
 func (l Lamport) Sign(sk, msg []byte) ([]byte, error) {
-	return l.scheme.Sign(sk, msg)
+	n := l.hash().Size()
+
+	seed, err := consume(sk, n+1)
+	if err != nil {
+		return nil, err
+	}
+
+	d := primitives.Digest(l.hash(), msg)
+
+	var sig []byte
+	for i := 0; i < 8*n; i++ {
+		sig = append(sig, l.secret(seed, i, bit(d, i))...)
+	}
+	return sig, nil
 }
 
 // Verify checks the validity of signature using a public key.
 func (l Lamport) Verify(pk, msg, sig []byte) error {
-	if !l.scheme.Verify(pk, msg, sig) {
-		return errors.New("unable to verify signature")
+	n := l.hash().Size()
+
+	if len(pk) != 2*8*n*n {
+		return fmt.Errorf("invalid public key size: %d", len(pk))
+	}
+	if len(sig) != 8*n*n {
+		return fmt.Errorf("invalid signature size: %d", len(sig))
+	}
+
+	d := primitives.Digest(l.hash(), msg)
+
+	for i := 0; i < 8*n; i++ {
+		j := 2*i + bit(d, i)
+		if !bytes.Equal(pk[j*n:(j+1)*n], primitives.Digest(l.hash(), sig[i*n:(i+1)*n])) {
+			return errors.New("unable to verify signature")
+		}
 	}
 	return nil
 }
+
+// secret derives the secret value for bit b at position i of the message digest.
+func (l Lamport) secret(seed []byte, i, b int) []byte {
+	var index [5]byte
+	binary.BigEndian.PutUint32(index[:4], uint32(i))
+	index[4] = byte(b)
+	return primitives.Digest(l.hash(), seed, index[:])
+}
+
+// bit returns the i-th most significant bit of d.
+func bit(d []byte, i int) int {
+	return int(d[i/8]>>uint(7-i%8)) & 1
+}
@@ -27,3 +27,22 @@ func TestLamport(t *testing.T) {
 	require.Nil(lamport.Verify(pk, msg, sig))
 	require.NotNil(lamport.Verify(pk, []byte("abc"), sig))
 }
+
+func TestLamport_OneTime(t *testing.T) {
+	require := require.New(t)
+
+	lamport := NewLamport(rand.Reader, sha256.New)
+
+	pk, sk, err := lamport.Generate()
+	require.Nil(err)
+
+	sig, err := lamport.Sign(sk, []byte("lamport"))
+	require.Nil(err)
+	require.Equal(8*sha256.Size*sha256.Size, len(sig))
+
+	_, err = lamport.Sign(sk, []byte("abc"))
+	require.Equal(ErrKeyUsed, err)
+
+	require.Nil(lamport.Verify(pk, []byte("lamport"), sig))
+	require.NotNil(lamport.Verify(pk, []byte("lamport"), sig[1:]))
+}
@@ -0,0 +1,448 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+
+	bin "github.com/alecthomas/binary"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// mmmMaxDepth is the maximum depth of an iterated sum composition.
+const mmmMaxDepth = 16
+
+// seeded is implemented by signature schemes that can deterministically derive a key pair
+// from a 32-byte seed.
+type seeded interface {
+	generate(seed []byte) (pk, sk []byte, err error)
+}
+
+// Sum implements the iterated sum composition proposed by Tal Malkin, Daniele Micciancio
+// and Sara Miner in their 2002 paper Efficient Generic Forward-Secure Signatures with an
+// Unbounded Number of Time Periods. It turns an ordinary signature scheme into a
+// forward-secure one with 2^depth periods by assigning a key pair of the underlying
+// scheme to each leaf of a hash tree whose root is the public key.
+//
+// The key pairs of the leaves are derived from seeds that are expanded along the tree.
+// A private key only holds the key pair of the current leaf and, for each level above
+// it, the roots of both subtrees and the seed of the right subtree. Once the periods of a
+// left subtree are exhausted, the right one is regrown from its seed and the seed is
+// erased. Signing and updating thus cost O(depth) space and amortised O(depth) key
+// generations, only key generation computes all 2^depth key pairs. This applies to
+// ECDSA, Ed25519, Ed448, WOTS and Lamport. Key pairs of any other scheme are generated
+// upfront and the private key stores those of the right subtrees in place of their
+// seeds, which takes O(2^depth) space. When combined with a one-time signature scheme
+// only a single message can be signed per period.
+type Sum struct {
+	signature Signature
+	depth     int
+}
+
+// sumSignature bundles signature material.
+type sumSignature struct {
+	PK   []byte // PK is the public key of the period.
+	Path []byte // Path is the concatenated authentication path of the period leaf.
+	Sig  []byte // Sig is the signature of the underlying scheme.
+
+	J int // J is the period in which the signature has been created.
+}
+
+// NewSum creates a fresh sum composition of a signature scheme with 2^depth periods. The
+// depth is limited to 16.
+func NewSum(signature Signature, depth int) *Sum {
+	return &Sum{signature: signature, depth: depth}
+}
+
+// MaxPeriod returns the last period in which a sum composition private key can be used.
+func (s Sum) MaxPeriod() int {
+	return 1<<uint(s.depth) - 1
+}
+
+// Generate creates a sum composition public/private key pair. The public key is the root
+// of the hash tree. The private key consists of the current period, the key pair of the
+// period and the seed and subtree roots of each level, see grow.
+func (s Sum) Generate() (pk, sk []byte, err error) {
+	if s.depth < 1 || s.depth > mmmMaxDepth {
+		return nil, nil, fmt.Errorf("unsupported sum composition depth: %d", s.depth)
+	}
+
+	var source []byte
+	if _, ok := s.signature.(seeded); ok {
+		source = make([]byte, sha256.Size)
+		if _, err := io.ReadFull(rand.Reader, source); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		keys := make([][]byte, 0, 2<<uint(s.depth))
+		for j := 0; j < 1<<uint(s.depth); j++ {
+			vk, sk, err := s.signature.Generate()
+			if err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, vk, sk)
+		}
+		source = pack(keys...)
+	}
+
+	fields := make([][]byte, 3+3*s.depth)
+	if pk, err = s.grow(source, s.depth, fields); err != nil {
+		return nil, nil, err
+	}
+	fields[0] = period(0)
+
+	sk = pack(fields...)
+	for i := range source {
+		source[i] = 0
+	}
+	return pk, sk, nil
+}
+
+// Update evolves a private key into a new period and erases the given private key in place.
+// If the new period is the first one of a right subtree, the subtree is regrown from its
+// seed or stored key pairs which are erased afterwards.
+func (s Sum) Update(sk []byte) ([]byte, error) {
+	fields, err := unpack(sk, 3+3*s.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	j := int(binary.BigEndian.Uint32(fields[0]))
+	if j >= s.MaxPeriod() {
+		return nil, errors.New("private key has surpassed max period")
+	}
+
+	// Level h is the lowest one in which the leaf path switches from the left into the
+	// right subtree, all levels below are replaced by those of the right subtree.
+	h := bits.TrailingZeros(uint(j+1)) + 1
+	source := fields[3*h]
+	if len(source) == 0 {
+		return nil, errors.New("invalid sum composition private key")
+	}
+	if _, err := s.grow(source, h-1, fields); err != nil {
+		return nil, err
+	}
+	fields[0], fields[3*h] = period(j+1), nil
+
+	upd := pack(fields...)
+	for i := range sk {
+		sk[i] = 0
+	}
+	return upd, nil
+}
+
+// Sign creates a signature of a given message with the key pair of the current period.
+// Changes of the underlying private key by the signing operation persist in sk.
+func (s Sum) Sign(sk, msg []byte) ([]byte, error) {
+	fields, err := unpack(sk, 3+3*s.depth)
+	if err != nil {
+		return nil, err
+	}
+	j := int(binary.BigEndian.Uint32(fields[0]))
+
+	sig, err := s.signature.Sign(fields[1], msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sibling on level h is the root of the subtree the leaf path does not enter.
+	var path []byte
+	for h := 1; h <= s.depth; h++ {
+		if (j>>uint(h-1))&1 == 0 {
+			path = append(path, fields[3*h+2]...)
+		} else {
+			path = append(path, fields[3*h+1]...)
+		}
+	}
+
+	return bin.Marshal(&sumSignature{PK: fields[2], Path: path, Sig: sig, J: j})
+}
+
+// Verify checks the validity of a given signature.
+func (s Sum) Verify(pk, msg, sig []byte) error {
+	var signature sumSignature
+	if err := bin.Unmarshal(sig, &signature); err != nil {
+		return err
+	}
+
+	n := sha256.Size
+	if signature.J < 0 || signature.J > s.MaxPeriod() || len(signature.Path) != s.depth*n {
+		return errors.New("invalid sum composition signature")
+	}
+
+	node := primitives.Digest(sha256.New(), []byte{0}, signature.PK)
+	for h := 0; h < s.depth; h++ {
+		sibling := signature.Path[h*n : (h+1)*n]
+		if (signature.J>>uint(h))&1 == 0 {
+			node = primitives.Digest(sha256.New(), []byte{1}, node, sibling)
+		} else {
+			node = primitives.Digest(sha256.New(), []byte{1}, sibling, node)
+		}
+	}
+	if !bytes.Equal(node, pk) {
+		return errors.New("unable to verify signature")
+	}
+
+	return s.signature.Verify(signature.PK, msg, signature.Sig)
+}
+
+// grow derives the hash tree of height h from a source and returns its root. The source
+// is a seed if the underlying scheme implements seeded and the packed key pairs of the
+// leaves otherwise. The key pair of the leftmost leaf is stored in fields[1] and
+// fields[2], the source of the right subtree and the roots of the left and right subtrees
+// of level k in fields[3k:3k+3]. The private keys of all other leaves are discarded.
+func (s Sum) grow(source []byte, h int, fields [][]byte) ([]byte, error) {
+	if h == 0 {
+		vk, sk, err := s.leaf(source)
+		if err != nil {
+			return nil, err
+		}
+		if fields != nil {
+			fields[1], fields[2] = sk, vk
+		}
+		return primitives.Digest(sha256.New(), []byte{0}, vk), nil
+	}
+
+	l, r, err := s.split(source, h)
+	if err != nil {
+		return nil, err
+	}
+
+	left, err := s.grow(l, h-1, fields)
+	if err != nil {
+		return nil, err
+	}
+	right, err := s.grow(r, h-1, nil)
+	if err != nil {
+		return nil, err
+	}
+	if fields != nil {
+		fields[3*h], fields[3*h+1], fields[3*h+2] = r, left, right
+	}
+	return primitives.Digest(sha256.New(), []byte{1}, left, right), nil
+}
+
+// leaf returns the key pair of a leaf source.
+func (s Sum) leaf(source []byte) (vk, sk []byte, err error) {
+	if base, ok := s.signature.(seeded); ok {
+		return base.generate(source)
+	}
+	keys, err := unpack(source, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys[0], keys[1], nil
+}
+
+// split divides the source of a tree of height h into the sources of its subtrees.
+func (s Sum) split(source []byte, h int) (left, right []byte, err error) {
+	if _, ok := s.signature.(seeded); ok {
+		n := sha256.Size
+		seeds := make([]byte, 2*n)
+		if _, err := io.ReadFull(primitives.NewDRBG(source, "sum"), seeds); err != nil {
+			return nil, nil, err
+		}
+		return seeds[:n], seeds[n:], nil
+	}
+	keys, err := unpack(source, 2<<uint(h))
+	if err != nil {
+		return nil, nil, err
+	}
+	half := 1 << uint(h)
+	return pack(keys[:half]...), pack(keys[half:]...), nil
+}
+
+// Product implements the product composition proposed by Tal Malkin, Daniele Micciancio
+// and Sara Miner. An outer forward-secure scheme certifies a fresh key pair of an inner
+// forward-secure scheme in each of its periods, such that the number of periods of both
+// schemes multiply. Inner key pairs are only generated once they are needed. Both schemes
+// have to implement Bounded.
+type Product struct {
+	outer, inner ForwardSignature
+}
+
+// productSignature bundles signature material.
+type productSignature struct {
+	PK   []byte // PK is the inner public key.
+	Cert []byte // Cert is the signature of the outer scheme on PK.
+	Sig  []byte // Sig is the signature of the inner scheme.
+}
+
+// NewProduct creates a fresh product composition of two bounded forward-secure schemes.
+func NewProduct(outer, inner ForwardSignature) *Product {
+	return &Product{outer: outer, inner: inner}
+}
+
+// MaxPeriod returns the last period in which a product composition private key can be used.
+func (p Product) MaxPeriod() int {
+	outer, inner, err := p.periods()
+	if err != nil {
+		return 0
+	}
+	return (outer+1)*(inner+1) - 1
+}
+
+// Generate creates a product composition public/private key pair. The public key is the
+// outer public key. The private key consists of the current outer and inner periods, the
+// outer private key and the certified inner key pair.
+func (p Product) Generate() (pk, sk []byte, err error) {
+	if _, _, err := p.periods(); err != nil {
+		return nil, nil, err
+	}
+
+	pk, osk, err := p.outer.Generate()
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, err = p.certify(0, osk)
+	return
+}
+
+// Update evolves a private key into a new period and erases the given private key in place.
+// The inner private key is updated until it reaches its last period, in which case the
+// outer private key is updated and a fresh inner key pair is certified.
+func (p Product) Update(sk []byte) ([]byte, error) {
+	outer, inner, err := p.periods()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := unpack(sk, 6)
+	if err != nil {
+		return nil, err
+	}
+	i, j := int(binary.BigEndian.Uint32(fields[0])), int(binary.BigEndian.Uint32(fields[1]))
+
+	var upd []byte
+	if j < inner {
+		isk, err := p.inner.Update(fields[3])
+		if err != nil {
+			return nil, err
+		}
+		fields[1], fields[3] = period(j+1), isk
+		upd = pack(fields...)
+	} else {
+		if i >= outer {
+			return nil, errors.New("private key has surpassed max period")
+		}
+		osk, err := p.outer.Update(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if upd, err = p.certify(i+1, osk); err != nil {
+			return nil, err
+		}
+	}
+
+	for k := range sk {
+		sk[k] = 0
+	}
+	return upd, nil
+}
+
+// Sign creates a signature of a given message with the inner private key. Changes of the
+// inner private key by the signing operation persist in sk.
+func (p Product) Sign(sk, msg []byte) ([]byte, error) {
+	fields, err := unpack(sk, 6)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := p.inner.Sign(fields[3], msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return bin.Marshal(&productSignature{PK: fields[4], Cert: fields[5], Sig: sig})
+}
+
+// Verify checks the validity of a given signature.
+func (p Product) Verify(pk, msg, sig []byte) error {
+	var signature productSignature
+	if err := bin.Unmarshal(sig, &signature); err != nil {
+		return err
+	}
+
+	if err := p.outer.Verify(pk, certificate(signature.PK), signature.Cert); err != nil {
+		return err
+	}
+	return p.inner.Verify(signature.PK, msg, signature.Sig)
+}
+
+// certify creates a fresh inner key pair and certifies it with the outer private key in a
+// given outer period.
+func (p Product) certify(i int, osk []byte) ([]byte, error) {
+	ipk, isk, err := p.inner.Generate()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := p.outer.Sign(osk, certificate(ipk))
+	if err != nil {
+		return nil, err
+	}
+	return pack(period(i), period(0), osk, isk, ipk, cert), nil
+}
+
+// periods returns the last periods of the outer and inner schemes.
+func (p Product) periods() (outer, inner int, err error) {
+	o, ok := p.outer.(Bounded)
+	if !ok {
+		return 0, 0, errors.New("outer scheme of product composition is not bounded")
+	}
+	i, ok := p.inner.(Bounded)
+	if !ok {
+		return 0, 0, errors.New("inner scheme of product composition is not bounded")
+	}
+	return o.MaxPeriod(), i.MaxPeriod(), nil
+}
+
+// certificate returns the message that is signed to certify an inner public key. The
+// outer period is not part of the message as it is bound by the outer signature itself.
+func certificate(pk []byte) []byte {
+	return primitives.Concat([]byte("product"), pk)
+}
+
+// period encodes a period as a fixed size integer.
+func period(j int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(j))
+	return b
+}
+
+// pack concatenates length-prefixed fields.
+func pack(fields ...[]byte) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = append(b, period(len(f))...)
+		b = append(b, f...)
+	}
+	return b
+}
+
+// unpack splits n length-prefixed fields. The fields refer to the underlying array of b,
+// hence in-place modifications of a field persist in b.
+func unpack(b []byte, n int) ([][]byte, error) {
+	fields := make([][]byte, n)
+	for i := range fields {
+		if len(b) < 4 {
+			return nil, errors.New("invalid private key encoding")
+		}
+		size := int(binary.BigEndian.Uint32(b))
+		if len(b) < 4+size {
+			return nil, errors.New("invalid private key encoding")
+		}
+		fields[i], b = b[4:4+size:4+size], b[4+size:]
+	}
+	if len(b) != 0 {
+		return nil, errors.New("invalid private key encoding")
+	}
+	return fields, nil
+}
@@ -0,0 +1,206 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSum(t *testing.T) {
+	require := require.New(t)
+
+	s := NewSum(NewEd25519(), 3)
+	pk, sk, err := s.Generate()
+	require.Nil(err)
+
+	msg := []byte("sum")
+
+	var sigs [][]byte
+	for i := 0; i <= s.MaxPeriod(); i++ {
+		for j := 0; j < 2; j++ {
+			sig, err := s.Sign(sk, msg)
+			require.Nil(err)
+			require.Nil(s.Verify(pk, msg, sig))
+			require.NotNil(s.Verify(pk, []byte("abc"), sig))
+			sigs = append(sigs, sig)
+		}
+
+		if i < s.MaxPeriod() {
+			old := sk
+			sk, err = s.Update(sk)
+			require.Nil(err)
+			require.Equal(make([]byte, len(old)), old)
+		}
+	}
+
+	_, err = s.Update(sk)
+	require.NotNil(err)
+
+	// Signatures of past periods remain valid.
+	for _, sig := range sigs {
+		require.Nil(s.Verify(pk, msg, sig))
+	}
+
+	_, _, err = NewSum(NewEd25519(), 0).Generate()
+	require.NotNil(err)
+}
+
+func TestSum_ECDSA(t *testing.T) {
+	testSum(t, NewSum(NewECDSA(elliptic.P256()), 2))
+}
+
+// unseeded hides the seeded key derivation of a signature scheme.
+type unseeded struct {
+	Signature
+}
+
+func TestSum_Unseeded(t *testing.T) {
+	require := require.New(t)
+
+	s := NewSum(unseeded{NewEd25519()}, 3)
+	testSum(t, s)
+
+	// The private key stores the key pairs of the right subtrees.
+	_, sk, err := s.Generate()
+	require.Nil(err)
+	_, seeded, err := NewSum(NewEd25519(), 3).Generate()
+	require.Nil(err)
+	require.True(len(sk) > len(seeded))
+}
+
+// testSum runs a sign, update and verify round trip over all periods of a sum composition.
+func testSum(t *testing.T, s *Sum) {
+	require := require.New(t)
+
+	pk, sk, err := s.Generate()
+	require.Nil(err)
+
+	msg := []byte("sum")
+
+	var sigs [][]byte
+	for i := 0; i <= s.MaxPeriod(); i++ {
+		sig, err := s.Sign(sk, msg)
+		require.Nil(err)
+		require.Nil(s.Verify(pk, msg, sig))
+		require.NotNil(s.Verify(pk, []byte("abc"), sig))
+		sigs = append(sigs, sig)
+
+		if i < s.MaxPeriod() {
+			sk, err = s.Update(sk)
+			require.Nil(err)
+		}
+	}
+
+	_, err = s.Update(sk)
+	require.NotNil(err)
+
+	for _, sig := range sigs {
+		require.Nil(s.Verify(pk, msg, sig))
+	}
+}
+
+func TestSum_Seeded(t *testing.T) {
+	require := require.New(t)
+
+	seed := make([]byte, sha256.Size)
+	_, err := rand.Read(seed)
+	require.Nil(err)
+
+	msg := []byte("seeded")
+
+	schemes := []Signature{
+		NewECDSA(elliptic.P256()), NewEd25519(), NewEd448(), NewWOTS(rand.Reader, sha256.New, 16), NewLamport(rand.Reader, sha256.New),
+	}
+	for _, scheme := range schemes {
+		pk1, sk1, err := scheme.(seeded).generate(seed)
+		require.Nil(err)
+		pk2, sk2, err := scheme.(seeded).generate(seed)
+		require.Nil(err)
+		require.Equal(pk1, pk2)
+		require.Equal(sk1, sk2)
+
+		sig, err := scheme.Sign(sk1, msg)
+		require.Nil(err)
+		require.Nil(scheme.Verify(pk1, msg, sig))
+	}
+}
+
+func TestSum_KeySize(t *testing.T) {
+	require := require.New(t)
+
+	// Each level adds a length-prefixed seed and two subtree roots to the private key.
+	small, large := NewSum(NewEd25519(), 4), NewSum(NewEd25519(), 8)
+	_, sk1, err := small.Generate()
+	require.Nil(err)
+	_, sk2, err := large.Generate()
+	require.Nil(err)
+	require.Equal(4*3*(4+sha256.Size), len(sk2)-len(sk1))
+
+	// Entering the right subtree of level 4 erases its seed.
+	sk := append([]byte{}, sk2...)
+	for i := 0; i < 8; i++ {
+		sk, err = large.Update(sk)
+		require.Nil(err)
+	}
+	require.Equal(len(sk2)-sha256.Size, len(sk))
+}
+
+func TestSum_OneTime(t *testing.T) {
+	require := require.New(t)
+
+	s := NewSum(NewWOTS(rand.Reader, sha256.New, 16), 2)
+	pk, sk, err := s.Generate()
+	require.Nil(err)
+
+	msg := []byte("sum")
+
+	for i := 0; i <= s.MaxPeriod(); i++ {
+		sig, err := s.Sign(sk, msg)
+		require.Nil(err)
+		require.Nil(s.Verify(pk, msg, sig))
+
+		_, err = s.Sign(sk, msg)
+		require.Equal(ErrKeyUsed, err)
+
+		if i < s.MaxPeriod() {
+			sk, err = s.Update(sk)
+			require.Nil(err)
+		}
+	}
+}
+
+func TestProduct(t *testing.T) {
+	require := require.New(t)
+
+	p := NewProduct(NewSum(NewEd25519(), 2), NewSum(NewWOTS(rand.Reader, sha256.New, 16), 2))
+	require.Equal(15, p.MaxPeriod())
+
+	pk, sk, err := p.Generate()
+	require.Nil(err)
+
+	msg := []byte("product")
+
+	for i := 0; i <= p.MaxPeriod(); i++ {
+		sig, err := p.Sign(sk, msg)
+		require.Nil(err)
+		require.Nil(p.Verify(pk, msg, sig))
+		require.NotNil(p.Verify(pk, []byte("abc"), sig))
+
+		_, err = p.Sign(sk, msg)
+		require.Equal(ErrKeyUsed, err)
+
+		if i < p.MaxPeriod() {
+			sk, err = p.Update(sk)
+			require.Nil(err)
+		}
+	}
+
+	_, err = p.Update(sk)
+	require.NotNil(err)
+}
@@ -0,0 +1,66 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// pssOptions fixes the salt length to the size of the SHA256 digest.
+var pssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+
+// PSS implements the RSA-PSS signature scheme based on SHA256.
+type PSS struct {
+	bits int
+}
+
+// NewPSS creates a fresh RSA-PSS instance whose keys have a modulus of the given size in
+// bits. Only the sizes primitives.RSA2048, primitives.RSA3072 and primitives.RSA4096 are
+// supported.
+func NewPSS(bits int) (*PSS, error) {
+	if err := primitives.CheckRSASize(bits); err != nil {
+		return nil, err
+	}
+	return &PSS{bits: bits}, nil
+}
+
+// Generate creates a fresh RSA-PSS public/private key pair.
+func (p PSS) Generate() (pk, sk []byte, err error) {
+	private, err := rsa.GenerateKey(rand.Reader, p.bits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pk = x509.MarshalPKCS1PublicKey(&private.PublicKey)
+	sk = x509.MarshalPKCS1PrivateKey(private)
+	return
+}
+
+// Sign creates a RSA-PSS signature of a given message.
+func (p PSS) Sign(sk, msg []byte) ([]byte, error) {
+	private, err := x509.ParsePKCS1PrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.SignPSS(rand.Reader, private, crypto.SHA256, primitives.Digest(sha256.New(), msg), pssOptions)
+}
+
+// Verify checks the validity of a RSA-PSS signature.
+func (p PSS) Verify(pk, msg, sig []byte) error {
+	public, err := x509.ParsePKCS1PublicKey(pk)
+	if err != nil {
+		return err
+	}
+	if err := rsa.VerifyPSS(public, crypto.SHA256, primitives.Digest(sha256.New(), msg), sig, pssOptions); err != nil {
+		return errors.New("unable to verify signature")
+	}
+	return nil
+}
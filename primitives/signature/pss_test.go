@@ -0,0 +1,34 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+func TestPSS(t *testing.T) {
+	require := require.New(t)
+
+	pss, err := NewPSS(primitives.RSA2048)
+	require.Nil(err)
+
+	pk, sk, err := pss.Generate()
+	require.Nil(err)
+
+	msg := []byte("rsa-pss")
+
+	sig, err := pss.Sign(sk, msg)
+	require.Nil(err)
+	require.Equal(primitives.RSA2048/8, len(sig))
+
+	require.Nil(pss.Verify(pk, msg, sig))
+	require.NotNil(pss.Verify(pk, []byte("abc"), sig))
+
+	_, err = NewPSS(1024)
+	require.NotNil(err)
+}
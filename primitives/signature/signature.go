@@ -5,11 +5,29 @@
 //
 // The following schemes are implemeted:
 //  - Lamport one-time signature
+//  - WOTS+ one-time signature
 //  - ECDSA
-//  - Bellare-Miner forward-secure signature
+//  - Ed25519 and Ed448
+//  - RSA-PSS
+//  - Bellare-Miner and Abdalla-Reyzin forward-secure signatures
+//  - XMSS forward-secure signature
+//  - MMM sum and product compositions of signature schemes
 //
 package signature
 
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrKeyUsed is returned when a one-time private key is used to sign a second message.
+var ErrKeyUsed = errors.New("one-time private key has already been used")
+
+// ErrStateful is returned by Sign of stateful schemes whose messages are signed with
+// SignUpdate.
+var ErrStateful = errors.New("stateful private key has to be advanced with SignUpdate")
+
 // Signature defines a common interface to which signature schemes have to conform.
 type Signature interface {
 	// Generate creates a public/private key pair.
@@ -27,3 +45,60 @@ type ForwardSignature interface {
 	// Update performs a key evolution on a private key.
 	Update(sk []byte) ([]byte, error)
 }
+
+// Bounded is implemented by forward-secure signature schemes whose keys can only
+// be evolved a fixed number of times.
+type Bounded interface {
+	// MaxPeriod returns the last period in which a private key can be used.
+	MaxPeriod() int
+}
+
+// Stateful is implemented by signature schemes whose private keys change with every
+// signature.
+type Stateful interface {
+	// SignUpdate creates a signature for a given message and returns the advanced
+	// private key that has to replace the given one.
+	SignUpdate(sk, msg []byte) (upd, sig []byte, err error)
+}
+
+// BatchVerifier is optionally implemented by signature schemes that can check several
+// signatures at once more efficiently than one after the other.
+type BatchVerifier interface {
+	// VerifyBatch checks the validity of the signatures sigs[i] of the messages msgs[i]
+	// under the public keys pks[i]. It fails if any of the signatures is invalid.
+	VerifyBatch(pks, msgs, sigs [][]byte) error
+}
+
+// consume checks that a one-time private key of the given size has not been used yet.
+// It returns a copy of the key material and erases the private key in place such that
+// any further signing attempt with the same key fails with ErrKeyUsed. The first byte of
+// a one-time private key marks whether it has been used.
+func consume(sk []byte, size int) ([]byte, error) {
+	if len(sk) != size {
+		return nil, fmt.Errorf("invalid private key size: %d", len(sk))
+	}
+	if sk[0] != 0 {
+		return nil, ErrKeyUsed
+	}
+
+	key := append([]byte{}, sk[1:]...)
+	sk[0] = 1
+	for i := 1; i < len(sk); i++ {
+		sk[i] = 0
+	}
+	return key, nil
+}
+
+// verifyEach checks a batch of signatures one after the other and returns the error of
+// the first invalid one.
+func verifyEach(verify func(pk, msg, sig []byte) error, pks, msgs, sigs [][]byte) error {
+	if len(pks) != len(sigs) || len(msgs) != len(sigs) {
+		return errors.New("batch sizes do not match")
+	}
+	for i := range sigs {
+		if err := verify(pks[i], msgs[i], sigs[i]); err != nil {
+			return errors.Wrapf(err, "invalid signature %d in batch", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/qantik/ratcheted/primitives"
+)
+
+// Domain separators of the WOTS+ hash function calls.
+const (
+	wotsF        byte = iota // chaining function.
+	wotsCompress             // public key compression.
+	wotsMessage              // message digest.
+	wotsPRF                  // pseudo-random function.
+)
+
+// Address types of the WOTS+ pseudo-random function calls.
+const (
+	wotsSecret uint32 = iota // secret chain start.
+	wotsKey                  // chaining function key.
+	wotsMask                 // chaining function bitmask.
+)
+
+// WOTS implements the Winternitz one-time signature scheme WOTS+ as described in
+// RFC 8391 for a given hash function and Winternitz parameter w. The hash function
+// calls are domain separated by a single byte prefix, and the chain ends of a public
+// key are compressed into a single digest. A private key can only be used once, Sign
+// erases it in place and refuses any further signature with ErrKeyUsed.
+//
+// The erasure only affects the given slice: a copy of the private key, e.g. one that is
+// part of a state serialised before signing, still produces signatures. Protocols using
+// WOTS+ therefore have to persist their state after every signature.
+type WOTS struct {
+	rand io.Reader
+	hash func() hash.Hash
+	w    int
+}
+
+// NewWOTS creates a fresh WOTS+ instance for a given hash function and Winternitz
+// parameter. Larger values of w yield shorter signatures at the expense of more hash
+// computations. Only the values 4, 16 and 256 are supported, all operations fail for any
+// other value.
+func NewWOTS(rand io.Reader, hash func() hash.Hash, w int) *WOTS {
+	return &WOTS{rand: rand, hash: hash, w: w}
+}
+
+// Generate creates a new public/secret key pair. The private key consists of a secret
+// and a public seed, the public key of the public seed and the compressed chain ends.
+func (w WOTS) Generate() (pk, sk []byte, err error) {
+	if err := w.check(); err != nil {
+		return nil, nil, err
+	}
+	n := w.hash().Size()
+
+	seeds := make([]byte, 2*n)
+	if _, err := io.ReadFull(w.rand, seeds); err != nil {
+		return nil, nil, err
+	}
+	skSeed, pubSeed := seeds[:n], seeds[n:]
+
+	pk = append(append([]byte{}, pubSeed...), w.publicKey(skSeed, pubSeed, 0)...)
+	sk = append([]byte{0}, seeds...)
+	return
+}
+
+// generate derives a public/secret key pair from a seed.
+func (w WOTS) generate(seed []byte) (pk, sk []byte, err error) {
+	w.rand = primitives.NewDRBG(seed, "wots")
+	return w.Generate()
+}
+
+// Sign creates a signature of a given message using a secret key.
+func (w WOTS) Sign(sk, msg []byte) ([]byte, error) {
+	if err := w.check(); err != nil {
+		return nil, err
+	}
+	n := w.hash().Size()
+
+	seeds, err := consume(sk, 2*n+1)
+	if err != nil {
+		return nil, err
+	}
+	skSeed, pubSeed := seeds[:n], seeds[n:]
+
+	return w.sign(skSeed, pubSeed, 0, w.digest(wotsMessage, pubSeed, msg)), nil
+}
+
+// Verify checks the validity of signature using a public key.
+func (w WOTS) Verify(pk, msg, sig []byte) error {
+	if err := w.check(); err != nil {
+		return err
+	}
+	n := w.hash().Size()
+
+	if len(pk) != 2*n {
+		return fmt.Errorf("invalid public key size: %d", len(pk))
+	}
+	pubSeed := pk[:n]
+
+	root, err := w.recover(pubSeed, 0, w.digest(wotsMessage, pubSeed, msg), sig)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, pk[n:]) {
+		return errors.New("unable to verify signature")
+	}
+	return nil
+}
+
+// check verifies that the Winternitz parameter is supported.
+func (w WOTS) check() error {
+	switch w.w {
+	case 4, 16, 256:
+		return nil
+	}
+	return fmt.Errorf("unsupported winternitz parameter: %d", w.w)
+}
+
+// logW returns the binary logarithm of the Winternitz parameter.
+func (w WOTS) logW() uint {
+	switch w.w {
+	case 4:
+		return 2
+	case 16:
+		return 4
+	}
+	return 8
+}
+
+// lengths returns the number of message and checksum chains.
+func (w WOTS) lengths() (len1, len2 int) {
+	logW := int(w.logW())
+
+	len1 = (8*w.hash().Size() + logW - 1) / logW
+	for max := len1 * (w.w - 1); max > 0; max >>= uint(logW) {
+		len2++
+	}
+	return
+}
+
+// digits returns the base-w representation of a message digest followed by its checksum.
+func (w WOTS) digits(m []byte) []int {
+	logW := w.logW()
+	len1, len2 := w.lengths()
+
+	base := func(x []byte, n int) []int {
+		digits := make([]int, n)
+		var total, bits uint
+		for i, j := 0, 0; i < n; i++ {
+			if bits == 0 {
+				total = uint(x[j])
+				j++
+				bits = 8
+			}
+			bits -= logW
+			digits[i] = int(total>>bits) & (w.w - 1)
+		}
+		return digits
+	}
+
+	digits := base(m, len1)
+
+	csum := 0
+	for _, d := range digits {
+		csum += w.w - 1 - d
+	}
+	size := (len2*int(logW) + 7) / 8
+	csum <<= uint(8*size - len2*int(logW))
+
+	c := make([]byte, 8)
+	binary.BigEndian.PutUint64(c, uint64(csum))
+	return append(digits, base(c[8-size:], len2)...)
+}
+
+// chain applies the chaining function steps times to x starting at position start.
+func (w WOTS) chain(pubSeed []byte, key, chain uint32, x []byte, start, steps int) []byte {
+	for i := start; i < start+steps; i++ {
+		k := w.prf(pubSeed, wotsKey, key, chain, uint32(i))
+		mask := w.prf(pubSeed, wotsMask, key, chain, uint32(i))
+
+		masked := make([]byte, len(x))
+		for j := range x {
+			masked[j] = x[j] ^ mask[j]
+		}
+		x = w.digest(wotsF, k, masked)
+	}
+	return x
+}
+
+// publicKey computes the compressed public key of the key pair with the given index.
+func (w WOTS) publicKey(skSeed, pubSeed []byte, key uint32) []byte {
+	len1, len2 := w.lengths()
+
+	ends := make([][]byte, len1+len2)
+	for i := range ends {
+		secret := w.prf(skSeed, wotsSecret, key, uint32(i), 0)
+		ends[i] = w.chain(pubSeed, key, uint32(i), secret, 0, w.w-1)
+	}
+	return w.compress(pubSeed, key, ends)
+}
+
+// sign creates the signature of a message digest with the key pair with the given index.
+func (w WOTS) sign(skSeed, pubSeed []byte, key uint32, m []byte) []byte {
+	var sig []byte
+	for i, d := range w.digits(m) {
+		secret := w.prf(skSeed, wotsSecret, key, uint32(i), 0)
+		sig = append(sig, w.chain(pubSeed, key, uint32(i), secret, 0, d)...)
+	}
+	return sig
+}
+
+// recover computes the compressed public key of the key pair with the given index from a
+// message digest and a signature.
+func (w WOTS) recover(pubSeed []byte, key uint32, m, sig []byte) ([]byte, error) {
+	n := w.hash().Size()
+	len1, len2 := w.lengths()
+
+	if len(sig) != (len1+len2)*n {
+		return nil, fmt.Errorf("invalid signature size: %d", len(sig))
+	}
+
+	ends := make([][]byte, len1+len2)
+	for i, d := range w.digits(m) {
+		ends[i] = w.chain(pubSeed, key, uint32(i), sig[i*n:(i+1)*n], d, w.w-1-d)
+	}
+	return w.compress(pubSeed, key, ends), nil
+}
+
+// compress hashes the chain ends of a public key into a single digest.
+func (w WOTS) compress(pubSeed []byte, key uint32, ends [][]byte) []byte {
+	return w.digest(wotsCompress, pubSeed, address(0, key, 0, 0), bytes.Join(ends, nil))
+}
+
+// prf is the keyed pseudo-random function used to derive secrets, keys and bitmasks.
+func (w WOTS) prf(seed []byte, typ, key, chain, step uint32) []byte {
+	return w.digest(wotsPRF, seed, address(typ, key, chain, step))
+}
+
+// digest applies the hash function on the domain separated data.
+func (w WOTS) digest(domain byte, data ...[]byte) []byte {
+	return primitives.Digest(w.hash(), append([][]byte{{domain}}, data...)...)
+}
+
+// address encodes the position of a hash function call.
+func address(typ, key, chain, step uint32) []byte {
+	adrs := make([]byte, 16)
+	binary.BigEndian.PutUint32(adrs[0:], typ)
+	binary.BigEndian.PutUint32(adrs[4:], key)
+	binary.BigEndian.PutUint32(adrs[8:], chain)
+	binary.BigEndian.PutUint32(adrs[12:], step)
+	return adrs
+}
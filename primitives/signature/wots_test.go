@@ -0,0 +1,56 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWOTS(t *testing.T) {
+	require := require.New(t)
+
+	for _, c := range []struct{ w, size int }{{4, 133}, {16, 67}, {256, 34}} {
+		wots := NewWOTS(rand.Reader, sha256.New, c.w)
+
+		pk, sk, err := wots.Generate()
+		require.Nil(err)
+
+		msg := []byte("wots")
+
+		sig, err := wots.Sign(sk, msg)
+		require.Nil(err)
+		require.Equal(c.size*sha256.Size, len(sig))
+
+		require.Nil(wots.Verify(pk, msg, sig))
+		require.NotNil(wots.Verify(pk, []byte("abc"), sig))
+		require.NotNil(wots.Verify(pk, msg, sig[1:]))
+
+		sig[0] ^= 1
+		require.NotNil(wots.Verify(pk, msg, sig))
+	}
+
+	_, _, err := NewWOTS(rand.Reader, sha256.New, 8).Generate()
+	require.NotNil(err)
+}
+
+func TestWOTS_OneTime(t *testing.T) {
+	require := require.New(t)
+
+	wots := NewWOTS(rand.Reader, sha256.New, 16)
+
+	pk, sk, err := wots.Generate()
+	require.Nil(err)
+
+	sig, err := wots.Sign(sk, []byte("wots"))
+	require.Nil(err)
+
+	_, err = wots.Sign(sk, []byte("abc"))
+	require.Equal(ErrKeyUsed, err)
+
+	require.Nil(wots.Verify(pk, []byte("wots"), sig))
+}
@@ -0,0 +1,416 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	bin "github.com/alecthomas/binary"
+)
+
+const (
+	xmssLayers      = 3  // number of hypertree layers within a period.
+	xmssLayerHeight = 4  // height of the trees in the hypertree layers.
+	xmssW           = 16 // winternitz parameter of the WOTS+ keys.
+	xmssMaxHeight   = 20 // maximum height of the period tree.
+)
+
+// Domain separators of the XMSS hash function calls, continuing those of WOTS+.
+const (
+	xmssNode   = wotsPRF + 1 + iota // tree node.
+	xmssEvolve                      // period seed evolution.
+)
+
+// xmssTree is the address type of the seeds of a hypertree layer tree.
+const xmssTree = wotsMask + 1
+
+// XMSS implements a forward-secure variant of the hash-based XMSS signature scheme
+// described in RFC 8391. The leaves of the period tree correspond to the key periods,
+// the WOTS+ key of leaf j signs the root of a hypertree of one-time keys with which the
+// messages of period j are signed. This allows for up to 2^12 signatures per period.
+//
+// The secrets of a period are derived from a period seed that is evolved with a one-way
+// function on each update, hence a private key cannot be used to sign messages of a past
+// period. A private key carries a counter of the signatures of the current period, so
+// XMSS is Stateful: messages are signed with SignUpdate, which returns the advanced
+// private key that has to be persisted in place of the given one.
+type XMSS struct {
+	wots   *WOTS
+	height int
+}
+
+// xmssPublicKey bundles the public key material.
+type xmssPublicKey struct {
+	Seed []byte // Seed is the public seed used to derive bitmasks and keys.
+	Root []byte // Root is the root of the period tree.
+}
+
+// xmssPrivateKey bundles the private key material.
+type xmssPrivateKey struct {
+	Seed    []byte         // Seed is the secret seed of the current period.
+	PubSeed []byte         // PubSeed is the public seed used to derive bitmasks and keys.
+	Auth    [][]byte       // Auth is the authentication path of the current period leaf.
+	Hash    []xmssTreehash // Hash holds the treehash instance of each level of the tree.
+
+	J int // J specifies the current period of this private key.
+}
+
+// xmssTreehash computes the next authentication node of a level of the period tree one
+// leaf per update. It only ever holds the seed of a current or future period.
+type xmssTreehash struct {
+	Seed  []byte   // Seed is the period seed of the next leaf.
+	Stack [][]byte // Stack holds the roots of the completed subtrees, the lowest one last.
+
+	Start, Next int // Start and Next are the first and the next leaf of the node.
+}
+
+// xmssSignature bundles signature material.
+type xmssSignature struct {
+	Sigs  [][]byte // Sigs holds the WOTS+ signatures from the bottom layer upwards.
+	Paths [][]byte // Paths holds the concatenated authentication paths of each layer.
+
+	J, C int // J and C are the period and the index of the signature within the period.
+}
+
+// NewXMSS creates a fresh XMSS instance whose keys can be evolved 2^height-1 times.
+//
+// Key generation computes all 2^height leaves of the period tree once. The private key
+// only stores the authentication path of the current period and a treehash instance per
+// level (Merkle tree traversal), i.e. O(height^2) nodes, and each Update computes at most
+// height leaves. Each Sign additionally recomputes the 48 WOTS+ keys of the hypertree
+// layers. The height is limited to 20, i.e. about a million updates, for which key
+// generation computes a million WOTS+ public keys.
+func NewXMSS(height int) *XMSS {
+	return &XMSS{wots: NewWOTS(rand.Reader, sha256.New, xmssW), height: height}
+}
+
+// MaxPeriod returns the last period in which a XMSS private key can be used.
+func (x XMSS) MaxPeriod() int {
+	return 1<<uint(x.height) - 1
+}
+
+// Generate creates a XMSS public/private key pair.
+func (x XMSS) Generate() (pk, sk []byte, err error) {
+	if x.height < 1 || x.height > xmssMaxHeight {
+		return nil, nil, fmt.Errorf("unsupported xmss tree height: %d", x.height)
+	}
+	n := sha256.Size
+
+	seeds := make([]byte, 2*n)
+	if _, err := io.ReadFull(rand.Reader, seeds); err != nil {
+		return nil, nil, err
+	}
+	seed, pubSeed := seeds[:n], seeds[n:]
+
+	private := &xmssPrivateKey{
+		Seed: seed, PubSeed: pubSeed,
+		Auth: make([][]byte, x.height), Hash: make([]xmssTreehash, x.height),
+	}
+
+	// The period tree is computed leaf by leaf keeping only the authentication path of
+	// the first period and the first node of each level, which is the next
+	// authentication node of its level.
+	top := x.treeSeed(pubSeed, xmssLayers, 0, 0)
+	var stack [][]byte
+	for j, s := 0, seed; j < 1<<uint(x.height); j++ {
+		node := x.leaf(s, top, j)
+		for h := 0; ; h++ {
+			i := j >> uint(h)
+			if i == 0 && h < x.height {
+				private.Hash[h] = xmssTreehash{Stack: [][]byte{node}, Next: 1 << uint(h)}
+			} else if i == 1 {
+				private.Auth[h] = node
+			}
+			if i&1 == 0 {
+				break
+			}
+			node = x.node(top, h+1, i/2, stack[len(stack)-1], node)
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, node)
+		s = x.wots.digest(xmssEvolve, s)
+	}
+
+	pk, err = bin.Marshal(&xmssPublicKey{Seed: pubSeed, Root: stack[0]})
+	if err != nil {
+		return
+	}
+	sk, err = x.marshal(private)
+	return
+}
+
+// Update evolves a private key into a new period. The period seed of the given private key
+// is erased in place.
+func (x XMSS) Update(sk []byte) ([]byte, error) {
+	_, private, err := x.unmarshal(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	if private.J >= x.MaxPeriod() {
+		return nil, errors.New("private key has surpassed max period")
+	}
+
+	private.Seed = x.wots.digest(xmssEvolve, private.Seed)
+	private.J++
+
+	// The authentication node of level h changes every 2^h periods and is replaced by
+	// the node computed by the treehash instance of the level, which then starts on the
+	// node that is needed next. The treehash instance adds one leaf per update, such
+	// that the node is complete when it is needed 2^h updates later.
+	top := x.treeSeed(private.PubSeed, xmssLayers, 0, 0)
+	for h := range private.Hash {
+		t := &private.Hash[h]
+		if private.J%(1<<uint(h)) == 0 {
+			private.Auth[h] = t.Stack[0]
+
+			start := (private.J + 1<<uint(h)) ^ 1<<uint(h)
+			*t = xmssTreehash{Stack: [][]byte{}, Start: start, Next: start}
+			if start <= x.MaxPeriod() {
+				t.Seed = private.Seed
+				for j := private.J; j < start; j++ {
+					t.Seed = x.wots.digest(xmssEvolve, t.Seed)
+				}
+			}
+		}
+		x.treehash(t, top, h)
+	}
+
+	upd, err := x.marshal(private)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sk {
+		sk[i] = 0
+	}
+	return upd, nil
+}
+
+// Sign fails since XMSS private keys have to be advanced with every signature, messages
+// are signed with SignUpdate.
+func (x XMSS) Sign(sk, msg []byte) ([]byte, error) {
+	return nil, ErrStateful
+}
+
+// SignUpdate creates a XMSS signature of a given message with the next one-time key of
+// the current period. It returns the private key with an advanced signature counter that
+// has to replace the given one, which is left untouched.
+func (x XMSS) SignUpdate(sk, msg []byte) (upd, sig []byte, err error) {
+	c, private, err := x.unmarshal(sk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c >= 1<<(xmssLayers*xmssLayerHeight) {
+		return nil, nil, errors.New("signatures of the current period are exhausted")
+	}
+
+	signature := &xmssSignature{J: private.J, C: c}
+
+	m := x.digest(private.PubSeed, private.J, c, msg)
+	for l := 0; l < xmssLayers; l++ {
+		tree, leaf := c>>uint((l+1)*xmssLayerHeight), c>>uint(l*xmssLayerHeight)&(1<<xmssLayerHeight-1)
+
+		skSeed := x.treeSeed(private.Seed, l, tree, private.J)
+		pubSeed := x.treeSeed(private.PubSeed, l, tree, private.J)
+
+		leaves := make([][]byte, 1<<xmssLayerHeight)
+		for k := range leaves {
+			leaves[k] = x.wots.publicKey(skSeed, pubSeed, uint32(k))
+		}
+		levels := x.levels(pubSeed, leaves)
+
+		signature.Sigs = append(signature.Sigs, x.wots.sign(skSeed, pubSeed, uint32(leaf), m))
+		signature.Paths = append(signature.Paths, x.path(levels, leaf))
+		m = levels[xmssLayerHeight][0]
+	}
+
+	// The root of the hypertree of a period is the same for all its signatures, such that
+	// the WOTS+ key of the period leaf always signs the same message.
+	skSeed := x.treeSeed(private.Seed, xmssLayers, 0, 0)
+	pubSeed := x.treeSeed(private.PubSeed, xmssLayers, 0, 0)
+
+	signature.Sigs = append(signature.Sigs, x.wots.sign(skSeed, pubSeed, uint32(private.J), m))
+	signature.Paths = append(signature.Paths, bytes.Join(private.Auth, nil))
+
+	sig, err = bin.Marshal(signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	upd = append([]byte{}, sk...)
+	binary.BigEndian.PutUint32(upd, uint32(c+1))
+	return
+}
+
+// Verify checks the validity of a given signature.
+func (x XMSS) Verify(pk, msg, sig []byte) error {
+	var public xmssPublicKey
+	if err := bin.Unmarshal(pk, &public); err != nil {
+		return err
+	}
+	var signature xmssSignature
+	if err := bin.Unmarshal(sig, &signature); err != nil {
+		return err
+	}
+
+	if len(signature.Sigs) != xmssLayers+1 || len(signature.Paths) != xmssLayers+1 {
+		return errors.New("invalid xmss signature")
+	}
+	if signature.J < 0 || signature.J > x.MaxPeriod() ||
+		signature.C < 0 || signature.C >= 1<<(xmssLayers*xmssLayerHeight) {
+		return errors.New("invalid xmss signature")
+	}
+
+	m := x.digest(public.Seed, signature.J, signature.C, msg)
+	for l := 0; l < xmssLayers; l++ {
+		c := signature.C
+		tree, leaf := c>>uint((l+1)*xmssLayerHeight), c>>uint(l*xmssLayerHeight)&(1<<xmssLayerHeight-1)
+
+		pubSeed := x.treeSeed(public.Seed, l, tree, signature.J)
+
+		node, err := x.wots.recover(pubSeed, uint32(leaf), m, signature.Sigs[l])
+		if err != nil {
+			return err
+		}
+		if m, err = x.root(pubSeed, node, leaf, signature.Paths[l], xmssLayerHeight); err != nil {
+			return err
+		}
+	}
+
+	pubSeed := x.treeSeed(public.Seed, xmssLayers, 0, 0)
+
+	node, err := x.wots.recover(pubSeed, uint32(signature.J), m, signature.Sigs[xmssLayers])
+	if err != nil {
+		return err
+	}
+	root, err := x.root(pubSeed, node, signature.J, signature.Paths[xmssLayers], x.height)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, public.Root) {
+		return errors.New("unable to verify signature")
+	}
+	return nil
+}
+
+// leaf computes the leaf of the period tree of a given period from its period seed.
+func (x XMSS) leaf(seed, pubSeed []byte, period int) []byte {
+	return x.wots.publicKey(x.treeSeed(seed, xmssLayers, 0, 0), pubSeed, uint32(period))
+}
+
+// treehash adds the next leaf to a treehash instance computing a node at a given height,
+// unless the node is complete or not needed anymore.
+func (x XMSS) treehash(t *xmssTreehash, pubSeed []byte, height int) {
+	if t.Start > x.MaxPeriod() || t.Next-t.Start == 1<<uint(height) {
+		return
+	}
+
+	node := x.leaf(t.Seed, pubSeed, t.Next)
+	for h := 0; (t.Next-t.Start)>>uint(h)&1 == 1; h++ {
+		node = x.node(pubSeed, h+1, t.Next>>uint(h+1), t.Stack[len(t.Stack)-1], node)
+		t.Stack = t.Stack[:len(t.Stack)-1]
+	}
+	t.Stack = append(t.Stack, node)
+	t.Next++
+
+	t.Seed = x.wots.digest(xmssEvolve, t.Seed)
+	if t.Next-t.Start == 1<<uint(height) {
+		t.Seed = nil
+	}
+}
+
+// levels computes all levels of a hash tree from its leaves.
+func (x XMSS) levels(pubSeed []byte, leaves [][]byte) [][][]byte {
+	levels := [][][]byte{leaves}
+	for h := 0; len(levels[h]) > 1; h++ {
+		var level [][]byte
+		for i := 0; i < len(levels[h]); i += 2 {
+			level = append(level, x.node(pubSeed, h+1, i/2, levels[h][i], levels[h][i+1]))
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// path returns the concatenated authentication path of a leaf.
+func (x XMSS) path(levels [][][]byte, leaf int) []byte {
+	var path []byte
+	for h := 0; h < len(levels)-1; h++ {
+		path = append(path, levels[h][(leaf>>uint(h))^1]...)
+	}
+	return path
+}
+
+// root computes the root of a hash tree of a given height from a leaf and its
+// authentication path.
+func (x XMSS) root(pubSeed, node []byte, leaf int, path []byte, height int) ([]byte, error) {
+	n := sha256.Size
+	if len(path) != height*n {
+		return nil, fmt.Errorf("invalid authentication path size: %d", len(path))
+	}
+
+	for h := 0; h < height; h++ {
+		sibling, i := path[h*n:(h+1)*n], leaf>>uint(h)
+		if i&1 == 0 {
+			node = x.node(pubSeed, h+1, i/2, node, sibling)
+		} else {
+			node = x.node(pubSeed, h+1, i/2, sibling, node)
+		}
+	}
+	return node, nil
+}
+
+// node computes the hash tree node at a given height and index from its two children.
+func (x XMSS) node(pubSeed []byte, height, index int, left, right []byte) []byte {
+	return x.wots.digest(xmssNode, pubSeed, address(0, uint32(height), uint32(index), 0), left, right)
+}
+
+// treeSeed derives the seed of a tree in a given layer from a secret or public seed.
+func (x XMSS) treeSeed(seed []byte, layer, tree, period int) []byte {
+	return x.wots.prf(seed, xmssTree, uint32(layer), uint32(tree), uint32(period))
+}
+
+// digest computes the message digest signed by the bottom layer.
+func (x XMSS) digest(pubSeed []byte, period, index int, msg []byte) []byte {
+	return x.wots.digest(wotsMessage, pubSeed, address(0, uint32(period), uint32(index), 0), msg)
+}
+
+// marshal encodes a private key preceded by a reset signature counter.
+func (x XMSS) marshal(private *xmssPrivateKey) ([]byte, error) {
+	sk, err := bin.Marshal(private)
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]byte, 4), sk...), nil
+}
+
+// unmarshal decodes the signature counter and the private key.
+func (x XMSS) unmarshal(sk []byte) (int, *xmssPrivateKey, error) {
+	if len(sk) < 4 {
+		return 0, nil, fmt.Errorf("invalid private key size: %d", len(sk))
+	}
+
+	var private xmssPrivateKey
+	if err := bin.Unmarshal(sk[4:], &private); err != nil {
+		return 0, nil, err
+	}
+	if len(private.Seed) != sha256.Size || len(private.PubSeed) != sha256.Size ||
+		len(private.Auth) != x.height || len(private.Hash) != x.height {
+		return 0, nil, errors.New("invalid xmss private key")
+	}
+	for h, t := range private.Hash {
+		// The treehash instance of a level must be complete when its node replaces the
+		// authentication node in the next update.
+		if private.J < x.MaxPeriod() && (private.J+1)%(1<<uint(h)) == 0 &&
+			(len(t.Stack) != 1 || t.Next-t.Start != 1<<uint(h)) {
+			return 0, nil, errors.New("invalid xmss private key")
+		}
+	}
+	return int(binary.BigEndian.Uint32(sk)), &private, nil
+}
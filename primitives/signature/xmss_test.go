@@ -0,0 +1,99 @@
+// (c) 2018 EPFL
+// This code is licensed under MIT license (see LICENSE.txt for details)
+
+package signature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMSS(t *testing.T) {
+	require := require.New(t)
+
+	x := NewXMSS(2)
+	pk, sk, err := x.Generate()
+	require.Nil(err)
+
+	msg := []byte("xmss")
+
+	for i := 0; i <= x.MaxPeriod(); i++ {
+		for j := 0; j < 3; j++ {
+			upd, sig, err := x.SignUpdate(sk, msg)
+			require.Nil(err)
+			require.Nil(x.Verify(pk, msg, sig))
+			require.NotNil(x.Verify(pk, []byte("abc"), sig))
+			sk = upd
+		}
+
+		if i < x.MaxPeriod() {
+			sk, err = x.Update(sk)
+			require.Nil(err)
+		}
+	}
+
+	_, err = x.Update(sk)
+	require.NotNil(err)
+
+	_, err = x.Sign(sk, msg)
+	require.Equal(ErrStateful, err)
+
+	_, _, err = NewXMSS(0).Generate()
+	require.NotNil(err)
+	_, _, err = NewXMSS(21).Generate()
+	require.NotNil(err)
+}
+
+func TestXMSS_Traversal(t *testing.T) {
+	require := require.New(t)
+
+	// The authentication path of every period is produced by the treehash instances.
+	x := NewXMSS(5)
+	pk, sk, err := x.Generate()
+	require.Nil(err)
+
+	msg := []byte("xmss")
+
+	for i := 0; i <= x.MaxPeriod(); i++ {
+		_, sig, err := x.SignUpdate(sk, msg)
+		require.Nil(err)
+		require.Nil(x.Verify(pk, msg, sig))
+
+		if i < x.MaxPeriod() {
+			sk, err = x.Update(sk)
+			require.Nil(err)
+		}
+	}
+}
+
+func TestXMSS_Forward(t *testing.T) {
+	require := require.New(t)
+
+	x := NewXMSS(2)
+	pk, sk, err := x.Generate()
+	require.Nil(err)
+
+	msg := []byte("xmss")
+
+	old := sk
+	sk, err = x.Update(sk)
+	require.Nil(err)
+
+	// The private key of the previous period is erased.
+	require.Equal(make([]byte, len(old)), old)
+	_, _, err = x.SignUpdate(old, msg)
+	require.NotNil(err)
+
+	// Every signature of a period is created with a fresh one-time key of the advanced
+	// private key, the given private key is left untouched.
+	prev := append([]byte{}, sk...)
+	upd, s1, err := x.SignUpdate(sk, msg)
+	require.Nil(err)
+	require.Equal(prev, sk)
+	_, s2, err := x.SignUpdate(upd, msg)
+	require.Nil(err)
+	require.NotEqual(s1, s2)
+	require.Nil(x.Verify(pk, msg, s1))
+	require.Nil(x.Verify(pk, msg, s2))
+}